@@ -4,10 +4,6 @@ package rocksgo
 // #include "rocksdb/c.h"
 import "C"
 
-import (
-	"unsafe"
-)
-
 // WriteBatch is a batching of Puts, and Deletes to be written atomically to a
 // database. A WriteBatch is written when passed to DB.Write.
 //
@@ -15,17 +11,26 @@ import (
 // WriteBatch object.
 type WriteBatch struct {
 	wbatch *C.rocksdb_writebatch_t
+	leak   *leakRecord
 }
 
 // NewWriteBatch creates a fully allocated WriteBatch.
 func NewWriteBatch() *WriteBatch {
-	wb := C.rocksdb_writebatch_create()
-	return &WriteBatch{wb}
+	w := &WriteBatch{wbatch: C.rocksdb_writebatch_create()}
+	w.leak = trackResource(w, "WriteBatch", nil)
+	return w
 }
 
 // Close releases the underlying memory of a WriteBatch.
+//
+// Closing a WriteBatch more than once is a no-op.
 func (w *WriteBatch) Close() {
+	if w.wbatch == nil {
+		return
+	}
 	C.rocksdb_writebatch_destroy(w.wbatch)
+	w.wbatch = nil
+	w.leak.untrack()
 }
 
 // Put places a key-value pair into the WriteBatch for writing later.
@@ -37,18 +42,9 @@ func (w *WriteBatch) Put(key, value []byte) {
 	// rocksdb_writebatch_put, and _delete call memcpy() (by way of
 	// Memtable::Add) when called, so we do not need to worry about these
 	// []byte being reclaimed by GC.
-	var k, v *C.char
-	if len(key) != 0 {
-		k = (*C.char)(unsafe.Pointer(&key[0]))
-	}
-	if len(value) != 0 {
-		v = (*C.char)(unsafe.Pointer(&value[0]))
-	}
-
-	lenk := len(key)
-	lenv := len(value)
-
-	C.rocksdb_writebatch_put(w.wbatch, k, C.size_t(lenk), v, C.size_t(lenv))
+	C.rocksdb_writebatch_put(w.wbatch,
+		byteSliceToChar(key), C.size_t(len(key)),
+		byteSliceToChar(value), C.size_t(len(value)))
 }
 
 // Delete queues a deletion of the data at key to be deleted later.
@@ -57,7 +53,18 @@ func (w *WriteBatch) Put(key, value []byte) {
 // them before returning.
 func (w *WriteBatch) Delete(key []byte) {
 	C.rocksdb_writebatch_delete(w.wbatch,
-		(*C.char)(unsafe.Pointer(&key[0])), C.size_t(len(key)))
+		byteSliceToChar(key), C.size_t(len(key)))
+}
+
+// DeleteRange queues a deletion of the keys from start up to but not
+// including limit.
+//
+// The byte slices may be reused safely. DeleteRange takes a copy of them
+// before returning.
+func (w *WriteBatch) DeleteRange(start, limit []byte) {
+	C.rocksdb_writebatch_delete_range(w.wbatch,
+		byteSliceToChar(start), C.size_t(len(start)),
+		byteSliceToChar(limit), C.size_t(len(limit)))
 }
 
 // Clear removes all the enqueued Put and Deletes in the WriteBatch.
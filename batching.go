@@ -0,0 +1,218 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMaxBatchBytes is the size a BatchingWriter lets a combined batch
+// grow to when NewBatchingWriter is given no limit.
+const DefaultMaxBatchBytes = 1 << 20
+
+// BatchingWriter coalesces the Puts, Deletes and Writes made concurrently
+// from many goroutines into a single WriteBatch, committed with one DB.Write.
+// With WriteOptions.SetSync(true), the whole group shares one fsync instead
+// of each call paying for its own.
+//
+// A commit window opens with the first call after the previous commit, and
+// collects the calls made until it is maxDelay old or the combined batch
+// holds maxBatchBytes. The calls return once the batch is written. The
+// updates of each call are applied atomically, but those of different calls
+// are only atomic by accident of landing in the same window.
+//
+// If the combined batch fails to be written, and so has not been applied,
+// the calls of the window are written again one at a time, and each returns
+// the error of its own updates: one bad call does not fail the others. A
+// failure of the database itself, such as an I/O error, is then returned by
+// every call.
+//
+// To wait for the last commit and stop the goroutine collecting calls,
+// Close must be called on a BatchingWriter when the program no longer needs
+// it, before closing the DB.
+type BatchingWriter struct {
+	db       *DB
+	wo       *WriteOptions
+	maxBytes int
+	maxDelay time.Duration
+
+	// mu guards closed, and is held for reading while sending on reqs so
+	// that Close cannot close reqs under a sender.
+	mu     sync.RWMutex
+	closed bool
+	reqs   chan *batchRequest
+	done   chan struct{}
+}
+
+type batchRequest struct {
+	key, value []byte
+	del        bool
+	wb         *WriteBatch
+	err        chan error
+}
+
+// NewBatchingWriter creates a BatchingWriter committing to db with wo. A
+// maxBatchBytes of zero or less means DefaultMaxBatchBytes. A maxDelay of
+// zero or less commits the calls waiting as soon as the previous commit is
+// done, without waiting for more.
+func NewBatchingWriter(db *DB, wo *WriteOptions, maxBatchBytes int, maxDelay time.Duration) *BatchingWriter {
+	if maxBatchBytes <= 0 {
+		maxBatchBytes = DefaultMaxBatchBytes
+	}
+	w := &BatchingWriter{
+		db:       db,
+		wo:       wo,
+		maxBytes: maxBatchBytes,
+		maxDelay: maxDelay,
+		reqs:     make(chan *batchRequest),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Put writes data associated with a key to the database in the next
+// commit, and returns once it is written.
+func (w *BatchingWriter) Put(key, value []byte) error {
+	return w.submit(&batchRequest{key: key, value: value})
+}
+
+// Delete removes the data associated with the key from the database in the
+// next commit, and returns once it is written.
+func (w *BatchingWriter) Delete(key []byte) error {
+	return w.submit(&batchRequest{key: key, del: true})
+}
+
+// Write adds every update of wb to the next commit, and returns once they
+// are written. wb must not be modified until Write returns. Writing a
+// closed WriteBatch returns ErrClosed.
+func (w *BatchingWriter) Write(wb *WriteBatch) error {
+	return w.submit(&batchRequest{wb: wb})
+}
+
+func (w *BatchingWriter) submit(r *batchRequest) error {
+	r.err = make(chan error, 1)
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return ErrWriterClosed
+	}
+	w.reqs <- r
+	w.mu.RUnlock()
+	return <-r.err
+}
+
+// Close commits the calls still waiting, and stops the BatchingWriter.
+// Calls made after Close return ErrWriterClosed.
+//
+// Closing a BatchingWriter more than once is a no-op.
+func (w *BatchingWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.reqs)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *BatchingWriter) run() {
+	defer close(w.done)
+	wb := NewWriteBatch()
+	defer wb.Close()
+
+	for first := range w.reqs {
+		waiting := w.add(wb, first, nil)
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if w.maxDelay > 0 {
+			timer = time.NewTimer(w.maxDelay)
+			timeout = timer.C
+		}
+	collect:
+		for batchBytes(wb) < w.maxBytes {
+			var r *batchRequest
+			ok := false
+			if timeout == nil {
+				select {
+				case r, ok = <-w.reqs:
+				default:
+				}
+			} else {
+				select {
+				case r, ok = <-w.reqs:
+				case <-timeout:
+				}
+			}
+			if !ok {
+				break collect
+			}
+			waiting = w.add(wb, r, waiting)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		var err error
+		if len(waiting) > 0 {
+			err = w.db.Write(w.wo, wb)
+		}
+		if err != nil && len(waiting) > 1 {
+			w.writeEach(wb, waiting)
+		} else {
+			for _, r := range waiting {
+				r.err <- err
+			}
+		}
+		wb.Clear()
+	}
+}
+
+// writeEach writes the updates of each call in waiting on its own, once
+// their combined batch failed, sending each call the error of its write.
+func (w *BatchingWriter) writeEach(wb *WriteBatch, waiting []*batchRequest) {
+	for _, r := range waiting {
+		wb.Clear()
+		if len(w.add(wb, r, nil)) == 0 {
+			// add has sent r its error already.
+			continue
+		}
+		r.err <- w.db.Write(w.wo, wb)
+	}
+}
+
+// add appends the updates of r to wb, and returns waiting with r added to
+// it. A WriteBatch that cannot be appended fails on its own, leaving wb and
+// waiting as they were.
+func (w *BatchingWriter) add(wb *WriteBatch, r *batchRequest, waiting []*batchRequest) []*batchRequest {
+	switch {
+	case r.wb != nil:
+		if r.wb.wbatch == nil {
+			// Appending it would crash the goroutine every call waits on.
+			r.err <- ErrClosed
+			return waiting
+		}
+		var errStr *C.char
+		C.rocksgo_writebatch_append(wb.wbatch, r.wb.wbatch, &errStr)
+		if err := statusError(errStr); err != nil {
+			r.err <- err
+			return waiting
+		}
+	case r.del:
+		wb.Delete(r.key)
+	default:
+		wb.Put(r.key, r.value)
+	}
+	return append(waiting, r)
+}
+
+// batchBytes returns the size of the serialized updates in wb.
+func batchBytes(wb *WriteBatch) int {
+	var n C.size_t
+	C.rocksdb_writebatch_data(wb.wbatch, &n)
+	return int(n)
+}
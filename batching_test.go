@@ -0,0 +1,117 @@
+package rocksgo
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchingWriter(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	wo.SetSync(true)
+	w := NewBatchingWriter(db, wo, 0, time.Millisecond)
+
+	if err := db.Put(wo, []byte("gone"), []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			k := []byte(fmt.Sprintf("key%02d", i))
+			if err := w.Put(k, k); err != nil {
+				t.Errorf("Put(%q) failed: %v", k, err)
+			}
+		}(i)
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := w.Delete([]byte("gone")); err != nil {
+			t.Errorf("Delete failed: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		wb := NewWriteBatch()
+		defer wb.Close()
+		wb.Put([]byte("batched1"), []byte("1"))
+		wb.Put([]byte("batched2"), []byte("2"))
+		if err := w.Write(wb); err != nil {
+			t.Errorf("Write failed: %v", err)
+		}
+	}()
+	wg.Wait()
+	w.Close()
+	w.Close()
+
+	for i := 0; i < 50; i++ {
+		k := []byte(fmt.Sprintf("key%02d", i))
+		if v, err := db.Get(ro, k); err != nil || string(v) != string(k) {
+			t.Errorf("Get(%q) = %q, %v", k, v, err)
+		}
+	}
+	for k, want := range map[string]string{"batched1": "1", "batched2": "2"} {
+		if v, err := db.Get(ro, []byte(k)); err != nil || string(v) != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", k, v, err, want)
+		}
+	}
+	if v, _ := db.Get(ro, []byte("gone")); v != nil {
+		t.Errorf("the deleted key is still there")
+	}
+	if err := w.Put([]byte("late"), nil); err != ErrWriterClosed {
+		t.Errorf("Put after Close returned %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestBatchingWriterClosedDB(t *testing.T) {
+	db, _, wo := openBinaryKeysDb(t)
+	w := NewBatchingWriter(db, wo, 1, 0)
+	defer w.Close()
+	db.Close()
+	if err := w.Put([]byte("a"), []byte("1")); err != ErrClosed {
+		t.Errorf("Put to a closed DB returned %v, want ErrClosed", err)
+	}
+}
+
+func TestBatchingWriterFailedGroup(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	// A long window gathers the calls below into one group, in which the
+	// Write of a closed WriteBatch must fail alone.
+	w := NewBatchingWriter(db, wo, 0, 50*time.Millisecond)
+	defer w.Close()
+	closed := NewWriteBatch()
+	closed.Put([]byte("bad"), []byte("x"))
+	closed.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			k := []byte(fmt.Sprintf("key%d", i))
+			if err := w.Put(k, k); err != nil {
+				t.Errorf("Put(%q) failed: %v", k, err)
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := w.Write(closed); err != ErrClosed {
+			t.Errorf("Write of a closed WriteBatch returned %v, want ErrClosed", err)
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < 5; i++ {
+		k := []byte(fmt.Sprintf("key%d", i))
+		if v, err := db.Get(ro, k); err != nil || string(v) != string(k) {
+			t.Errorf("Get(%q) = %q, %v", k, v, err)
+		}
+	}
+	if v, _ := db.Get(ro, []byte("bad")); v != nil {
+		t.Errorf("the key of the closed WriteBatch was written")
+	}
+}
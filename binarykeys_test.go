@@ -0,0 +1,174 @@
+package rocksgo
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+)
+
+// binaryKey generates short keys over an alphabet heavy in NUL and 0xff
+// bytes, so that empty keys, embedded NULs and keys that are prefixes of
+// each other come up often.
+type binaryKey []byte
+
+func (binaryKey) Generate(r *rand.Rand, size int) reflect.Value {
+	alphabet := []byte{0x00, 0x00, 0x01, 'a', 0xff}
+	k := make(binaryKey, r.Intn(8))
+	for i := range k {
+		k[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return reflect.ValueOf(k)
+}
+
+func openBinaryKeysDb(t *testing.T) (*DB, *ReadOptions, *WriteOptions) {
+	dbname := tempDir(t)
+	options := NewOptions()
+	options.SetErrorIfExists(true)
+	options.SetCreateIfMissing(true)
+	ro := NewReadOptions()
+	wo := NewWriteOptions()
+	_ = DestroyDatabase(dbname, options)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		options.Close()
+		ro.Close()
+		wo.Close()
+		deleteDBDirectory(t, dbname)
+	})
+	return db, ro, wo
+}
+
+func TestBinaryKeysInDb(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+
+	roundTrip := func(key binaryKey, value []byte) bool {
+		if err := db.Put(wo, key, value); err != nil {
+			t.Errorf("Put(%q) failed: %v", key, err)
+			return false
+		}
+		got, err := db.Get(ro, key)
+		if err != nil || got == nil || !bytes.Equal(got, value) {
+			t.Errorf("Get(%q) = %q, %v; want %q", key, got, err, value)
+			return false
+		}
+
+		it := db.NewIterator(ro)
+		defer it.Close()
+		it.Seek(key)
+		if !it.Valid() || !bytes.Equal(it.Key(), key) || !bytes.Equal(it.Value(), value) {
+			t.Errorf("Seek(%q) did not land on the key just written", key)
+			return false
+		}
+
+		wb := NewWriteBatch()
+		defer wb.Close()
+		wb.Delete(key)
+		if err := db.Write(wo, wb); err != nil {
+			t.Errorf("Write deleting %q failed: %v", key, err)
+			return false
+		}
+		got, err = db.Get(ro, key)
+		if err != nil || got != nil {
+			t.Errorf("Get(%q) after WriteBatch.Delete = %q, %v; want nil", key, got, err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBinaryKeysIterationOrder(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+
+	model := make(map[string]bool)
+	sameOrder := func(put, batched, deleted []binaryKey) bool {
+		for _, k := range put {
+			if err := db.Put(wo, k, k); err != nil {
+				t.Errorf("Put(%q) failed: %v", k, err)
+				return false
+			}
+			model[string(k)] = true
+		}
+		wb := NewWriteBatch()
+		defer wb.Close()
+		for _, k := range batched {
+			wb.Put(k, k)
+			model[string(k)] = true
+		}
+		for _, k := range deleted {
+			wb.Delete(k)
+			delete(model, string(k))
+		}
+		if err := db.Write(wo, wb); err != nil {
+			t.Errorf("Write failed: %v", err)
+			return false
+		}
+
+		want := make([]string, 0, len(model))
+		for k := range model {
+			want = append(want, k)
+		}
+		sort.Strings(want)
+
+		var got []string
+		it := db.NewIterator(ro)
+		defer it.Close()
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			if !bytes.Equal(it.Key(), it.Value()) {
+				t.Errorf("key %q has value %q", it.Key(), it.Value())
+			}
+			got = append(got, string(it.Key()))
+		}
+		if err := it.GetError(); err != nil {
+			t.Errorf("iteration failed: %v", err)
+			return false
+		}
+		if len(got) != len(want) {
+			t.Errorf("iterated %q, want %q", got, want)
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("iterated %q, want %q", got, want)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(sameOrder, &quick.Config{MaxCount: 100}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBinaryKeysApproximateSizes(t *testing.T) {
+	db, _, _ := openBinaryKeysDb(t)
+
+	if sizes := db.GetApproximateSizes(nil); sizes == nil || len(sizes) != 0 {
+		t.Errorf("GetApproximateSizes(nil) = %v, want an empty slice", sizes)
+	}
+	onePerRange := func(starts, limits []binaryKey) bool {
+		ranges := make([]Range, len(starts))
+		for i := range starts {
+			ranges[i].Start = starts[i]
+			if i < len(limits) {
+				ranges[i].Limit = limits[i]
+			}
+		}
+		return len(db.GetApproximateSizes(ranges)) == len(ranges)
+	}
+	if err := quick.Check(onePerRange, nil); err != nil {
+		t.Error(err)
+	}
+	if err := db.CompactRange(Range{[]byte{0x00}, []byte{0x00, 0x00}}); err != nil {
+		t.Errorf("CompactRange over NUL keys failed: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package rocksgo
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ananclub/rocksgo/internal/keyrange"
+)
+
+// Bucket is a view of the keys of a DB starting with a prefix, as if they
+// were a database of their own: keys are prefixed on the way in, stripped
+// on the way out, and its Iterators never leave the prefix.
+//
+// Buckets are cheap, holding nothing but their prefix, and sharing the DB's
+// resources. For buckets of one DB to be independent, no prefix may be a
+// prefix of another; fixed-length prefixes, or the tuple encodings of
+// package keys, ensure that.
+type Bucket struct {
+	db     *DB
+	prefix []byte
+	// limit is the first key after the bucket, nil if there is none.
+	limit []byte
+}
+
+// Bucket returns the Bucket of the keys of db starting with prefix.
+func (db *DB) Bucket(prefix []byte) *Bucket {
+	prefix = append([]byte(nil), prefix...)
+	return &Bucket{db: db, prefix: prefix, limit: keyrange.PrefixEnd(prefix)}
+}
+
+// Bucket returns the Bucket nested in b under prefix, that is the Bucket of
+// the DB whose prefix is that of b followed by prefix.
+func (b *Bucket) Bucket(prefix []byte) *Bucket {
+	return b.db.Bucket(b.key(prefix))
+}
+
+// Prefix returns the prefix of the keys of the bucket in the DB.
+func (b *Bucket) Prefix() []byte {
+	return append([]byte(nil), b.prefix...)
+}
+
+func (b *Bucket) key(key []byte) []byte {
+	k := make([]byte, 0, len(b.prefix)+len(key))
+	return append(append(k, b.prefix...), key...)
+}
+
+// Get is DB.Get for the bucket.
+func (b *Bucket) Get(ro *ReadOptions, key []byte) ([]byte, error) {
+	return b.db.Get(ro, b.key(key))
+}
+
+// Put is DB.Put for the bucket.
+func (b *Bucket) Put(wo *WriteOptions, key, value []byte) error {
+	return b.db.Put(wo, b.key(key), value)
+}
+
+// Delete is DB.Delete for the bucket.
+func (b *Bucket) Delete(wo *WriteOptions, key []byte) error {
+	return b.db.Delete(wo, b.key(key))
+}
+
+// Batch returns a view of wb staging updates to the bucket, to be written
+// atomically with DB.Write along with any other update in wb.
+func (b *Bucket) Batch(wb *WriteBatch) BucketBatch {
+	return BucketBatch{b: b, wb: wb}
+}
+
+// errUnboundedBucket is returned by Drop for a bucket with no key after it.
+var errUnboundedBucket = errors.New("rocksgo: bucket prefix is empty or all 0xff bytes, and cannot be dropped")
+
+// Drop deletes every key of the bucket, nested buckets included, with a
+// single range deletion rather than one deletion per key. A bucket whose
+// prefix is empty or all 0xff bytes cannot be dropped.
+func (b *Bucket) Drop(wo *WriteOptions) error {
+	if b.limit == nil {
+		return errUnboundedBucket
+	}
+	wb := NewWriteBatch()
+	defer wb.Close()
+	wb.DeleteRange(b.prefix, b.limit)
+	return b.db.Write(wo, wb)
+}
+
+// NewIterator returns an Iterator over the keys of the bucket, as for
+// DB.NewIterator. It must be closed in the same way.
+func (b *Bucket) NewIterator(ro *ReadOptions) *BucketIterator {
+	return &BucketIterator{it: b.db.NewIterator(ro), b: b}
+}
+
+// BucketBatch stages updates to a Bucket in a WriteBatch. It is returned
+// by Bucket.Batch.
+type BucketBatch struct {
+	b  *Bucket
+	wb *WriteBatch
+}
+
+// Put is WriteBatch.Put for the bucket.
+func (bb BucketBatch) Put(key, value []byte) {
+	bb.wb.Put(bb.b.key(key), value)
+}
+
+// Delete is WriteBatch.Delete for the bucket.
+func (bb BucketBatch) Delete(key []byte) {
+	bb.wb.Delete(bb.b.key(key))
+}
+
+// DeleteRange is WriteBatch.DeleteRange for the bucket. A nil limit
+// deletes up to the end of the bucket.
+func (bb BucketBatch) DeleteRange(start, limit []byte) {
+	end := bb.b.limit
+	if limit != nil {
+		end = bb.b.key(limit)
+	}
+	bb.wb.DeleteRange(bb.b.key(start), end)
+}
+
+// BucketIterator is an Iterator over the keys of a Bucket, returned by
+// Bucket.NewIterator. Its keys are stripped of the prefix of the bucket, and
+// it becomes invalid when moved past either end of the bucket.
+type BucketIterator struct {
+	it *Iterator
+	b  *Bucket
+}
+
+// Valid is Iterator.Valid for the bucket.
+func (bi *BucketIterator) Valid() bool {
+	return bi.it.Valid() && bytes.HasPrefix(bi.it.rawKey(), bi.b.prefix)
+}
+
+// Key returns a copy of the key the iterator currently holds, without the
+// prefix of the bucket.
+//
+// If Valid returns false, this method will panic.
+func (bi *BucketIterator) Key() []byte {
+	return bytes.Clone(bi.it.rawKey()[len(bi.b.prefix):])
+}
+
+// Value is Iterator.Value for the bucket.
+func (bi *BucketIterator) Value() []byte {
+	return bi.it.Value()
+}
+
+// Next is Iterator.Next for the bucket.
+func (bi *BucketIterator) Next() {
+	bi.it.Next()
+}
+
+// Prev is Iterator.Prev for the bucket.
+func (bi *BucketIterator) Prev() {
+	bi.it.Prev()
+}
+
+// SeekToFirst moves the iterator to the first key of the bucket.
+func (bi *BucketIterator) SeekToFirst() {
+	bi.it.Seek(bi.b.prefix)
+}
+
+// SeekToLast moves the iterator to the last key of the bucket.
+func (bi *BucketIterator) SeekToLast() {
+	if bi.b.limit == nil {
+		bi.it.SeekToLast()
+		return
+	}
+	bi.it.SeekForPrev(bi.b.limit)
+	// SeekForPrev lands on limit itself if it is a key, which is past the
+	// end of the bucket.
+	if bi.it.Valid() && bytes.Equal(bi.it.rawKey(), bi.b.limit) {
+		bi.it.Prev()
+	}
+}
+
+// Seek is Iterator.Seek for the bucket.
+func (bi *BucketIterator) Seek(key []byte) {
+	bi.it.Seek(bi.b.key(key))
+}
+
+// SeekForPrev is Iterator.SeekForPrev for the bucket.
+func (bi *BucketIterator) SeekForPrev(key []byte) {
+	bi.it.SeekForPrev(bi.b.key(key))
+}
+
+// GetError is Iterator.GetError for the bucket.
+func (bi *BucketIterator) GetError() error {
+	return bi.it.GetError()
+}
+
+// Close is Iterator.Close for the bucket.
+func (bi *BucketIterator) Close() {
+	bi.it.Close()
+}
@@ -0,0 +1,109 @@
+package rocksgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func bucketKeys(t *testing.T, bi *BucketIterator, backward bool) string {
+	var ks []string
+	if backward {
+		for bi.SeekToLast(); bi.Valid(); bi.Prev() {
+			ks = append(ks, string(bi.Key()))
+		}
+	} else {
+		for bi.SeekToFirst(); bi.Valid(); bi.Next() {
+			ks = append(ks, string(bi.Key())+"="+string(bi.Value()))
+		}
+	}
+	if err := bi.GetError(); err != nil {
+		t.Errorf("iteration failed: %v", err)
+	}
+	return strings.Join(ks, ",")
+}
+
+func TestBucket(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	for _, k := range []string{"a", "b/", "c"} {
+		if err := db.Put(wo, []byte(k), []byte("outside")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	b := db.Bucket([]byte("b/"))
+	nested := b.Bucket([]byte("n/"))
+	for _, k := range []string{"x", "y"} {
+		if err := b.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	wb := NewWriteBatch()
+	defer wb.Close()
+	nested.Batch(wb).Put([]byte("z"), []byte("z"))
+	b.Batch(wb).Delete([]byte("y"))
+	if err := db.Write(wo, wb); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if v, err := b.Get(ro, []byte("x")); err != nil || string(v) != "x" {
+		t.Errorf("Get(x) = %q, %v", v, err)
+	}
+	if v, err := db.Get(ro, []byte("b/n/z")); err != nil || string(v) != "z" {
+		t.Errorf("the nested bucket wrote %q, %v under b/n/z", v, err)
+	}
+	if string(nested.Prefix()) != "b/n/" {
+		t.Errorf("nested prefix is %q", nested.Prefix())
+	}
+
+	it := b.NewIterator(ro)
+	if got := bucketKeys(t, it, false); got != "=outside,n/z=z,x=x" {
+		t.Errorf("iterated %q", got)
+	}
+	if got := bucketKeys(t, it, true); got != "x,n/z," {
+		t.Errorf("iterated backward %q", got)
+	}
+	it.Seek([]byte("o"))
+	if !it.Valid() || string(it.Key()) != "x" {
+		t.Errorf("Seek(o) should land on x")
+	}
+	it.Next()
+	if it.Valid() {
+		t.Errorf("the iterator should stop at the end of the bucket")
+	}
+	it.Close()
+
+	if err := nested.Drop(wo); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	it = b.NewIterator(ro)
+	if got := bucketKeys(t, it, false); got != "=outside,x=x" {
+		t.Errorf("after dropping the nested bucket, iterated %q", got)
+	}
+	it.Close()
+	if err := b.Drop(wo); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	for _, k := range []string{"a", "c"} {
+		if v, _ := db.Get(ro, []byte(k)); string(v) != "outside" {
+			t.Errorf("dropping the bucket removed %q", k)
+		}
+	}
+	if v, _ := db.Get(ro, []byte("b/x")); v != nil {
+		t.Errorf("dropping the bucket left b/x")
+	}
+	if err := db.Bucket(nil).Drop(wo); err == nil {
+		t.Errorf("dropping the whole keyspace should fail")
+	}
+}
+
+func TestBucketAtEndOfKeyspace(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	b := db.Bucket([]byte{0xff})
+	if err := b.Put(wo, []byte("k"), []byte("v")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	it := b.NewIterator(ro)
+	defer it.Close()
+	if got := bucketKeys(t, it, true); got != "k" {
+		t.Errorf("iterated backward %q", got)
+	}
+}
@@ -15,6 +15,7 @@ import "C"
 // this may not be necessary and could be avoided to shorten shutdown time.
 type Cache struct {
 	Cache *C.rocksdb_cache_t
+	leak  *leakRecord
 }
 
 // NewLRUCache creates a new Cache object with the capacity given.
@@ -23,10 +24,19 @@ type Cache struct {
 // program no longer needs it. Note: if the process is shutting down, this may
 // not be necessary and could be avoided to shorten shutdown time.
 func NewLRUCache(capacity int) *Cache {
-	return &Cache{C.rocksdb_cache_create_lru(C.size_t(capacity))}
+	c := &Cache{Cache: C.rocksdb_cache_create_lru(C.size_t(capacity))}
+	c.leak = trackResource(c, "Cache", nil)
+	return c
 }
 
 // Close deallocates the underlying memory of the Cache object.
+//
+// Closing a Cache more than once is a no-op.
 func (c *Cache) Close() {
+	if c.Cache == nil {
+		return
+	}
 	C.rocksdb_cache_destroy(c.Cache)
+	c.Cache = nil
+	c.leak.untrack()
 }
@@ -0,0 +1,271 @@
+package rocksgo
+
+// This file holds the Go functions rocksdb calls back into. cgo does not
+// allow C definitions in the preamble of a file using //export, so the C
+// glue passing them to rocksdb lives next to the Go types they serve.
+
+// #include <stddef.h>
+// #include <stdlib.h>
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// handleRegistry hands out integer ids for Go values that C code holds on
+// to, so that no Go pointer is ever given to C. Unlike cgo.Handle, looking
+// up an id that is no longer registered is not an error: rocksdb may still
+// call back after the Go side has been closed, and those calls are dropped.
+type handleRegistry[T any] struct {
+	mu   sync.RWMutex
+	next uintptr
+	m    map[uintptr]T
+}
+
+func (r *handleRegistry[T]) register(v T) uintptr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.m == nil {
+		r.m = make(map[uintptr]T)
+	}
+	// Ids start at 1 so that a zero id never refers to anything.
+	r.next++
+	r.m[r.next] = v
+	return r.next
+}
+
+func (r *handleRegistry[T]) unregister(id uintptr) {
+	r.mu.Lock()
+	delete(r.m, id)
+	r.mu.Unlock()
+}
+
+// get returns the value registered under id.
+func (r *handleRegistry[T]) get(id uintptr) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.m[id]
+	return v, ok
+}
+
+// len returns the number of values registered.
+func (r *handleRegistry[T]) len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.m)
+}
+
+// with calls f with the value registered under id, if there is one. The
+// value cannot be unregistered while f runs, so f must not block.
+func (r *handleRegistry[T]) with(id uintptr, f func(T)) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if v, ok := r.m[id]; ok {
+		f(v)
+	}
+}
+
+var infoLoggers handleRegistry[*InfoLogger]
+
+//export rocksgoInfoLog
+func rocksgoInfoLog(priv unsafe.Pointer, level C.uint, msg *C.char, n C.size_t) {
+	line := C.GoStringN(msg, C.int(n))
+	infoLoggers.with(uintptr(priv), func(il *InfoLogger) {
+		il.enqueue(InfoLogLevel(level), line)
+	})
+}
+
+// eventListeners is only released once rocksdb has destroyed the listener
+// forwarding to it, so no event can be lost to a concurrent unregister.
+var eventListeners handleRegistry[EventListener]
+
+//export rocksgoListenerRelease
+func rocksgoListenerRelease(id C.uintptr_t) {
+	eventListeners.unregister(uintptr(id))
+}
+
+//export rocksgoOnFlushCompleted
+func rocksgoOnFlushCompleted(id C.uintptr_t, info *C.rocksgo_flush_job_info_t) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnFlushCompleted(FlushJobInfo{
+			JobID:                   int(info.job_id),
+			ColumnFamily:            C.GoString(info.cf_name),
+			FilePath:                C.GoString(info.file_path),
+			Reason:                  FlushReason(info.reason),
+			SmallestSeqno:           uint64(info.smallest_seqno),
+			LargestSeqno:            uint64(info.largest_seqno),
+			NumEntries:              uint64(info.num_entries),
+			DataSize:                uint64(info.data_size),
+			TriggeredWritesSlowdown: info.triggered_writes_slowdown != 0,
+			TriggeredWritesStop:     info.triggered_writes_stop != 0,
+		})
+	}
+}
+
+//export rocksgoOnCompactionCompleted
+func rocksgoOnCompactionCompleted(id C.uintptr_t, info *C.rocksgo_compaction_job_info_t) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnCompactionCompleted(CompactionJobInfo{
+			JobID:          int(info.job_id),
+			ColumnFamily:   C.GoString(info.cf_name),
+			Err:            callbackStatus(info.status),
+			Reason:         CompactionReason(info.reason),
+			BaseInputLevel: int(info.base_input_level),
+			OutputLevel:    int(info.output_level),
+			InputFiles:     goStrings(info.input_files, info.num_input_files),
+			OutputFiles:    goStrings(info.output_files, info.num_output_files),
+			Duration:       time.Duration(info.elapsed_micros) * time.Microsecond,
+			InputBytes:     uint64(info.total_input_bytes),
+			OutputBytes:    uint64(info.total_output_bytes),
+			InputRecords:   uint64(info.num_input_records),
+			OutputRecords:  uint64(info.num_output_records),
+		})
+	}
+}
+
+//export rocksgoOnTableFileCreated
+func rocksgoOnTableFileCreated(id C.uintptr_t, info *C.rocksgo_table_file_creation_info_t) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnTableFileCreated(TableFileCreationInfo{
+			JobID:        int(info.job_id),
+			DBName:       C.GoString(info.db_name),
+			ColumnFamily: C.GoString(info.cf_name),
+			FilePath:     C.GoString(info.file_path),
+			Err:          callbackStatus(info.status),
+			Reason:       TableFileCreationReason(info.reason),
+			FileSize:     uint64(info.file_size),
+			NumEntries:   uint64(info.num_entries),
+		})
+	}
+}
+
+//export rocksgoOnTableFileDeleted
+func rocksgoOnTableFileDeleted(id C.uintptr_t, info *C.rocksgo_table_file_deletion_info_t) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnTableFileDeleted(TableFileDeletionInfo{
+			JobID:    int(info.job_id),
+			DBName:   C.GoString(info.db_name),
+			FilePath: C.GoString(info.file_path),
+			Err:      callbackStatus(info.status),
+		})
+	}
+}
+
+//export rocksgoOnStallConditionsChanged
+func rocksgoOnStallConditionsChanged(id C.uintptr_t, cfName *C.char, cur, prev C.int) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnStallConditionsChanged(WriteStallInfo{
+			ColumnFamily: C.GoString(cfName),
+			Condition:    WriteStallCondition(cur),
+			Previous:     WriteStallCondition(prev),
+		})
+	}
+}
+
+//export rocksgoOnBackgroundError
+func rocksgoOnBackgroundError(id C.uintptr_t, reason C.int, status *C.char) {
+	if l, ok := eventListeners.get(uintptr(id)); ok {
+		l.OnBackgroundError(BackgroundErrorInfo{
+			Reason: BackgroundErrorReason(reason),
+			Err:    callbackStatus(status),
+		})
+	}
+}
+
+// callbackStatus converts a status handed to a callback, which still
+// belongs to rocksdb, into an error.
+func callbackStatus(status *C.char) error {
+	if status == nil {
+		return nil
+	}
+	return parseError(C.GoString(status))
+}
+
+func goStrings(p **C.char, n C.size_t) []string {
+	if n == 0 {
+		return nil
+	}
+	out := make([]string, n)
+	for i, s := range unsafe.Slice(p, n) {
+		out[i] = C.GoString(s)
+	}
+	return out
+}
+
+//export rocksgoEncryptionNewPrefix
+func rocksgoEncryptionNewPrefix(id C.uintptr_t, prefix *C.char, n C.size_t) *C.char {
+	kp, ok := keyProviders.get(uintptr(id))
+	if !ok {
+		return cError(errUnknownHandle("KeyProvider", id))
+	}
+	return cError(newEncryptionPrefix(kp, unsafeBytes(prefix, n)))
+}
+
+//export rocksgoEncryptionOpenStream
+func rocksgoEncryptionOpenStream(id C.uintptr_t, prefix *C.char, n C.size_t, stream *C.uintptr_t) *C.char {
+	kp, ok := keyProviders.get(uintptr(id))
+	if !ok {
+		return cError(errUnknownHandle("KeyProvider", id))
+	}
+	s, err := openCipherStream(kp, unsafeBytes(prefix, n))
+	if err != nil {
+		return cError(err)
+	}
+	*stream = C.uintptr_t(cipherStreams.register(s))
+	return nil
+}
+
+//export rocksgoEncryptionRelease
+func rocksgoEncryptionRelease(id C.uintptr_t) {
+	keyProviders.unregister(uintptr(id))
+}
+
+//export rocksgoCipherStreamXOR
+func rocksgoCipherStreamXOR(id C.uintptr_t, offset C.uint64_t, data *C.char, n C.size_t) *C.char {
+	s, ok := cipherStreams.get(uintptr(id))
+	if !ok {
+		return cError(errUnknownHandle("cipher stream", id))
+	}
+	s.xor(uint64(offset), unsafeBytes(data, n))
+	return nil
+}
+
+//export rocksgoCipherStreamRelease
+func rocksgoCipherStreamRelease(id C.uintptr_t) {
+	cipherStreams.unregister(uintptr(id))
+}
+
+//export rocksgoMergeFull
+func rocksgoMergeFull(id C.uintptr_t, key *C.char, keyLen C.size_t, existing *C.char, existingLen C.size_t,
+	operands **C.char, operandLens *C.size_t, n C.int, success *C.uchar, newLen *C.size_t) *C.char {
+	m, ok := mergeOperators.get(uintptr(id))
+	if !ok {
+		*success = 0
+		*newLen = 0
+		return nil
+	}
+	return m.fullMerge(key, keyLen, existing, existingLen, operands, operandLens, n, success, newLen)
+}
+
+// rocksgoMergeName returns nil for an unknown id, which the C glue replaces
+// with a name of its own.
+//
+//export rocksgoMergeName
+func rocksgoMergeName(id C.uintptr_t) *C.char {
+	m, ok := mergeOperators.get(uintptr(id))
+	if !ok {
+		return nil
+	}
+	return m.name
+}
+
+//export rocksgoMergeRelease
+func rocksgoMergeRelease(id C.uintptr_t) {
+	if m, ok := mergeOperators.get(uintptr(id)); ok {
+		mergeOperators.unregister(uintptr(id))
+		C.free(unsafe.Pointer(m.name))
+	}
+}
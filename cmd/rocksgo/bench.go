@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ananclub/rocksgo"
+)
+
+func init() {
+	register(&command{
+		name:  "bench",
+		short: "run db_bench style benchmarks against the database",
+		flags: func(c *cmdContext, fs *flag.FlagSet) { c.bench.register(fs) },
+		db:    dbPath,
+		run:   runBench,
+	})
+}
+
+// benchFlags are the flags of the bench command.
+type benchFlags struct {
+	benchmarks string
+	num        int
+	reads      int
+	threads    int
+	keySize    int
+	valueSize  int
+	batchSize  int
+	seed       int64
+	sync       bool
+
+	writeBufferSize int
+	compactionStyle string
+	compression     string
+	bloomBits       int
+	blockSize       int
+	cacheSize       int
+	maxBGJobs       int
+}
+
+func (b *benchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&b.benchmarks, "benchmarks", "fillrandom,readrandom",
+		"comma-separated `list` of benchmarks, run in order: "+strings.Join(benchmarkNames(), ", "))
+	fs.IntVar(&b.num, "num", 100000, "number of keys, and of writes")
+	fs.IntVar(&b.reads, "reads", 0, "number of reads; 0 reads -num keys")
+	fs.IntVar(&b.threads, "threads", 1, "number of goroutines running each benchmark")
+	fs.IntVar(&b.keySize, "key-size", 16, "size of the keys, at least 8 bytes")
+	fs.IntVar(&b.valueSize, "value-size", 100, "size of the values")
+	fs.IntVar(&b.batchSize, "batch-size", 1, "number of writes per WriteBatch, and of keys per MultiGet")
+	fs.Int64Var(&b.seed, "seed", 1, "seed of the random keys and values")
+	fs.BoolVar(&b.sync, "sync", false, "sync every write")
+	fs.IntVar(&b.writeBufferSize, "write-buffer-size", 64<<20, "Options.SetWriteBufferSize, in bytes")
+	fs.StringVar(&b.compactionStyle, "compaction-style", "level", "Options.SetCompactionStyle: level or universal")
+	fs.StringVar(&b.compression, "compression", "snappy", "Options.SetCompression: none, snappy, zlib or bzip2")
+	fs.IntVar(&b.bloomBits, "bloom-bits", 10, "bits per key of a bloom filter; 0 uses none")
+	fs.IntVar(&b.blockSize, "block-size", 4096, "Options.SetBlockSize, in bytes")
+	fs.IntVar(&b.cacheSize, "cache-size", 8<<20, "size of the block cache, in bytes")
+	fs.IntVar(&b.maxBGJobs, "max-background-compactions", 1, "Options.SetMaxBackgroundCompactions")
+}
+
+// benchmark is a workload of the bench command.
+type benchmark struct {
+	name string
+	// write is set for the benchmarks writing to the database, after which
+	// the write amplification is reported.
+	write bool
+	// ops is the number of operations of the benchmark, shared among the
+	// threads.
+	ops func(b *benchFlags) int
+	// run runs the operations i, i+step, i+2*step... below n, timing every
+	// call to the database with t.
+	run func(r *benchRun, t *benchThread, i, step, n int) error
+}
+
+var benchmarks = []*benchmark{
+	{name: "fillseq", write: true, ops: numOps, run: fill(false)},
+	{name: "fillrandom", write: true, ops: numOps, run: fill(true)},
+	{name: "overwrite", write: true, ops: numOps, run: fill(true)},
+	{name: "readrandom", ops: readOps, run: (*benchRun).readRandom},
+	{name: "readseq", ops: readOps, run: (*benchRun).readSeq},
+	{name: "readwhilewriting", ops: readOps, run: (*benchRun).readRandom},
+	{name: "multireadrandom", ops: readOps, run: (*benchRun).multiReadRandom},
+	{name: "deleterandom", write: true, ops: numOps, run: (*benchRun).deleteRandom},
+}
+
+func benchmarkNames() []string {
+	names := make([]string, len(benchmarks))
+	for i, bm := range benchmarks {
+		names[i] = bm.name
+	}
+	return names
+}
+
+func numOps(b *benchFlags) int { return b.num }
+
+func readOps(b *benchFlags) int {
+	if b.reads > 0 {
+		return b.reads
+	}
+	return b.num
+}
+
+// benchRun holds the database the benchmarks run against.
+type benchRun struct {
+	flags *benchFlags
+	db    *rocksgo.DB
+	ro    *rocksgo.ReadOptions
+	wo    *rocksgo.WriteOptions
+	// userBytes counts the bytes of the keys and values written by the
+	// benchmarks, against which the write amplification is measured.
+	userBytes uint64
+}
+
+// benchThread is a goroutine running a benchmark.
+type benchThread struct {
+	rand      *rand.Rand
+	latencies []time.Duration
+	// done counts the operations done; a MultiGet or a WriteBatch counts
+	// as one operation per key.
+	done  int
+	found int
+	bytes uint64
+}
+
+// time runs f, recording how long it took.
+func (t *benchThread) time(f func() error) error {
+	start := time.Now()
+	err := f()
+	t.latencies = append(t.latencies, time.Since(start))
+	return err
+}
+
+func (r *benchRun) key(dst []byte, i int) []byte {
+	dst = binary.BigEndian.AppendUint64(dst[:0], uint64(i))
+	for len(dst) < r.flags.keySize {
+		dst = append(dst, '0')
+	}
+	return dst
+}
+
+// value fills dst with random printable bytes.
+func (r *benchRun) value(t *benchThread, dst []byte) []byte {
+	dst = dst[:0]
+	for len(dst) < r.flags.valueSize {
+		dst = append(dst, byte('a'+t.rand.Intn(26)))
+	}
+	return dst
+}
+
+// fill writes keys in order, or random ones.
+func fill(random bool) func(*benchRun, *benchThread, int, int, int) error {
+	return func(r *benchRun, t *benchThread, i, step, n int) error {
+		wb := rocksgo.NewWriteBatch()
+		defer wb.Close()
+		var key, value []byte
+		for i < n {
+			wb.Clear()
+			for j := 0; j < r.flags.batchSize && i < n; j++ {
+				k := i
+				if random {
+					k = t.rand.Intn(r.flags.num)
+				}
+				key, value = r.key(key, k), r.value(t, value)
+				wb.Put(key, value)
+				t.bytes += uint64(len(key) + len(value))
+				t.done++
+				i += step
+			}
+			if err := t.time(func() error { return r.db.Write(r.wo, wb) }); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (r *benchRun) deleteRandom(t *benchThread, i, step, n int) error {
+	wb := rocksgo.NewWriteBatch()
+	defer wb.Close()
+	var key []byte
+	for i < n {
+		wb.Clear()
+		for j := 0; j < r.flags.batchSize && i < n; j++ {
+			key = r.key(key, t.rand.Intn(r.flags.num))
+			wb.Delete(key)
+			t.bytes += uint64(len(key))
+			t.done++
+			i += step
+		}
+		if err := t.time(func() error { return r.db.Write(r.wo, wb) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *benchRun) readRandom(t *benchThread, i, step, n int) error {
+	var key []byte
+	for ; i < n; i += step {
+		key = r.key(key, t.rand.Intn(r.flags.num))
+		var v []byte
+		err := t.time(func() (err error) {
+			v, err = r.db.Get(r.ro, key)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			t.found++
+			t.bytes += uint64(len(key) + len(v))
+		}
+		t.done++
+	}
+	return nil
+}
+
+func (r *benchRun) multiReadRandom(t *benchThread, i, step, n int) error {
+	keys := make([][]byte, r.flags.batchSize)
+	for i < n {
+		keys = keys[:0]
+		for ; len(keys) < cap(keys) && i < n; i += step {
+			keys = append(keys, r.key(nil, t.rand.Intn(r.flags.num)))
+		}
+		var values [][]byte
+		err := t.time(func() (err error) {
+			values, err = r.db.MultiGet(r.ro, keys)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for j, v := range values {
+			if v != nil {
+				t.found++
+				t.bytes += uint64(len(keys[j]) + len(v))
+			}
+		}
+		t.done += len(keys)
+	}
+	return nil
+}
+
+// readSeq has every thread scan its own share of the keys, those from
+// i*num/step up to (i+1)*num/step, until it has done its share of the
+// operations, or reached the end of its keys.
+func (r *benchRun) readSeq(t *benchThread, i, step, n int) error {
+	num := r.flags.num
+	var limit []byte
+	if i < step-1 {
+		limit = r.key(nil, (i+1)*num/step)
+	}
+	it := r.db.NewIterator(r.ro)
+	defer it.Close()
+	it.Seek(r.key(nil, i*num/step))
+	for ; i < n && it.Valid(); i += step {
+		if limit != nil && bytes.Compare(it.Key(), limit) >= 0 {
+			break
+		}
+		t.time(func() error {
+			t.bytes += uint64(len(it.Key()) + len(it.Value()))
+			it.Next()
+			return nil
+		})
+		t.found++
+		t.done++
+	}
+	return it.GetError()
+}
+
+// benchResult is what a benchmark reports.
+type benchResult struct {
+	Benchmark string  `json:"benchmark"`
+	Ops       int     `json:"ops"`
+	Found     int     `json:"found,omitempty"`
+	Seconds   float64 `json:"seconds"`
+	OpsPerSec float64 `json:"ops_per_sec"`
+	MBPerSec  float64 `json:"mb_per_sec"`
+	// Latencies are in microseconds, per call to the database.
+	Latency  map[string]float64 `json:"latency_us"`
+	WriteAmp *float64           `json:"write_amp,omitempty"`
+
+	write bool
+}
+
+func runBench(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	b := &c.bench
+	if c.readOnly {
+		return errors.New("bench writes to the database, and cannot be run with -read-only")
+	}
+	if b.keySize < 8 {
+		return errors.New("-key-size must be at least 8")
+	}
+	if b.threads < 1 || b.batchSize < 1 || b.num < 1 {
+		return errors.New("-threads, -batch-size and -num must be positive")
+	}
+	var run []*benchmark
+	for _, name := range strings.Split(b.benchmarks, ",") {
+		i := slices.IndexFunc(benchmarks, func(bm *benchmark) bool { return bm.name == strings.TrimSpace(name) })
+		if i < 0 {
+			return fmt.Errorf("unknown benchmark %q", name)
+		}
+		run = append(run, benchmarks[i])
+	}
+
+	r := &benchRun{flags: b}
+	cleanup, err := r.open(c)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	for _, bm := range run {
+		res, err := r.run(bm)
+		if err != nil {
+			return fmt.Errorf("%s: %w", bm.name, err)
+		}
+		if err := printBenchResult(c, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// open opens the database with the options given by the flags.
+func (r *benchRun) open(c *cmdContext) (cleanup func(), err error) {
+	b, o := r.flags, c.options
+	o.SetCreateIfMissing(true)
+	o.SetWriteBufferSize(b.writeBufferSize)
+	o.SetBlockSize(b.blockSize)
+	o.SetMaxBackgroundCompactions(b.maxBGJobs)
+	switch b.compactionStyle {
+	case "level":
+		o.SetCompactionStyle(rocksgo.LevelCompactionStyle)
+	case "universal":
+		o.SetCompactionStyle(rocksgo.UniversalCompactionStyle)
+	default:
+		return nil, fmt.Errorf("unknown compaction style %q", b.compactionStyle)
+	}
+	compressions := map[string]rocksgo.CompressionType{
+		"none":   rocksgo.NoCompression,
+		"snappy": rocksgo.SnappyCompression,
+		"zlib":   rocksgo.ZlibCompression,
+		"bzip2":  rocksgo.BZip2Compression,
+	}
+	compression, ok := compressions[b.compression]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression %q", b.compression)
+	}
+	o.SetCompression(compression)
+	var closers []func()
+	cleanup = func() {
+		for _, f := range slices.Backward(closers) {
+			f()
+		}
+	}
+	if b.bloomBits > 0 {
+		fp := rocksgo.NewBloomFilter(b.bloomBits)
+		closers = append(closers, fp.Close)
+		o.SetFilterPolicy(fp)
+	}
+	if b.cacheSize > 0 {
+		cache := rocksgo.NewLRUCache(b.cacheSize)
+		closers = append(closers, cache.Close)
+		o.SetCache(cache)
+	} else {
+		o.SetNoBlockCache(true)
+	}
+	r.ro = rocksgo.NewReadOptions()
+	r.wo = rocksgo.NewWriteOptions()
+	r.wo.SetSync(b.sync)
+	closers = append(closers, r.ro.Close, r.wo.Close)
+	if r.db, err = rocksgo.Open(c.dbPath, o); err != nil {
+		cleanup()
+		return nil, err
+	}
+	closers = append(closers, func() { r.db.Close() })
+	return cleanup, nil
+}
+
+func (r *benchRun) run(bm *benchmark) (*benchResult, error) {
+	n := bm.ops(r.flags)
+	threads := make([]*benchThread, r.flags.threads)
+	errs := make([]error, len(threads))
+	var wg sync.WaitGroup
+
+	// readwhilewriting adds a thread overwriting random keys until the
+	// readers are done.
+	stop := make(chan struct{})
+	var writerErr error
+	var writer sync.WaitGroup
+	if bm.name == "readwhilewriting" {
+		w := &benchThread{rand: rand.New(rand.NewSource(r.flags.seed - 1))}
+		writer.Add(1)
+		go func() {
+			defer writer.Done()
+			overwrite := fill(true)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if writerErr = overwrite(r, w, 0, 1, r.flags.batchSize); writerErr != nil {
+					return
+				}
+				r.userBytes += w.bytes
+				w.bytes, w.latencies = 0, w.latencies[:0]
+			}
+		}()
+	}
+
+	start := time.Now()
+	for i := range threads {
+		threads[i] = &benchThread{rand: rand.New(rand.NewSource(r.flags.seed + int64(i)))}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = bm.run(r, threads[i], i, len(threads), n)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(stop)
+	writer.Wait()
+	if err := errors.Join(append(errs, writerErr)...); err != nil {
+		return nil, err
+	}
+
+	res := &benchResult{Benchmark: bm.name, Seconds: elapsed.Seconds(), Latency: map[string]float64{}, write: bm.write}
+	var latencies []time.Duration
+	var totalBytes uint64
+	for _, t := range threads {
+		res.Ops += t.done
+		res.Found += t.found
+		totalBytes += t.bytes
+		latencies = append(latencies, t.latencies...)
+	}
+	res.OpsPerSec = float64(res.Ops) / elapsed.Seconds()
+	res.MBPerSec = float64(totalBytes) / (1 << 20) / elapsed.Seconds()
+	slices.Sort(latencies)
+	for _, p := range []float64{50, 95, 99, 99.9} {
+		res.Latency["p"+strconv.FormatFloat(p, 'f', -1, 64)] = micros(percentile(latencies, p))
+	}
+	if len(latencies) > 0 {
+		res.Latency["max"] = micros(latencies[len(latencies)-1])
+	}
+	if bm.write {
+		r.userBytes += totalBytes
+	}
+	if bm.write || bm.name == "readwhilewriting" {
+		if wa, ok := r.writeAmp(); ok {
+			res.WriteAmp = &wa
+		}
+	}
+	return res, nil
+}
+
+// writeAmp returns the bytes written to SST files by flushes and
+// compactions so far, over the bytes written by the benchmarks. The writes
+// still in the memtable are not in SST files yet, so it reports nothing
+// until a flush has happened, rather than an amplification of zero; it is
+// only meaningful once most of the writes have been flushed, as when they
+// are many times the -write-buffer-size.
+func (r *benchRun) writeAmp() (float64, bool) {
+	stats, ok := r.db.GetMapProperty("rocksdb.cfstats")
+	if !ok || r.userBytes == 0 {
+		return 0, false
+	}
+	gb, err := strconv.ParseFloat(stats["compaction.Sum.WriteGB"], 64)
+	if err != nil || gb == 0 {
+		return 0, false
+	}
+	return gb * (1 << 30) / float64(r.userBytes), true
+}
+
+// percentile returns the p-th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+func printBenchResult(c *cmdContext, res *benchResult) error {
+	if c.out.json {
+		return c.out.object(res)
+	}
+	fmt.Fprintf(c.stdout, "%-16s : %10.3f micros/op %10.0f ops/sec %8.1f MB/s",
+		res.Benchmark, 1e6/res.OpsPerSec, res.OpsPerSec, res.MBPerSec)
+	if !res.write {
+		fmt.Fprintf(c.stdout, " (%d of %d found)", res.Found, res.Ops)
+	}
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintf(c.stdout, "%-16s   latency us: p50 %.1f p95 %.1f p99 %.1f p99.9 %.1f max %.1f\n", "",
+		res.Latency["p50"], res.Latency["p95"], res.Latency["p99"], res.Latency["p99.9"], res.Latency["max"])
+	if res.WriteAmp != nil {
+		fmt.Fprintf(c.stdout, "%-16s   write amplification: %.2f\n", "", *res.WriteAmp)
+	}
+	return nil
+}
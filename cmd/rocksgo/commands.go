@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"iter"
+	"strings"
+
+	"github.com/ananclub/rocksgo"
+	"github.com/ananclub/rocksgo/keys"
+)
+
+func init() {
+	register(&command{
+		name:  "get",
+		args:  "KEY",
+		short: "print the value of KEY",
+		db:    dbRead,
+		run:   runGet,
+	})
+	register(&command{
+		name:  "put",
+		args:  "KEY VALUE",
+		short: "set the value of KEY",
+		db:    dbWrite,
+		run:   runPut,
+	})
+	register(&command{
+		name:  "delete",
+		args:  "KEY",
+		short: "delete KEY",
+		db:    dbWrite,
+		run:   runDelete,
+	})
+	register(&command{
+		name:  "scan",
+		short: "print the keys and values of a range of keys",
+		flags: func(c *cmdContext, fs *flag.FlagSet) {
+			c.rng.register(fs)
+			fs.IntVar(&c.rng.max, "limit", 0, "print at most `n` keys; 0 prints them all")
+			fs.BoolVar(&c.rng.reverse, "reverse", false, "print the keys in reverse order")
+		},
+		db:  dbRead,
+		run: runScan,
+	})
+	register(&command{
+		name:  "count",
+		short: "print the number of keys in a range of keys",
+		flags: func(c *cmdContext, fs *flag.FlagSet) { c.rng.register(fs) },
+		db:    dbRead,
+		run:   runCount,
+	})
+	register(&command{
+		name:  "approxsize",
+		short: "print the approximate size on disk of a range of keys",
+		flags: func(c *cmdContext, fs *flag.FlagSet) { c.rng.register(fs) },
+		db:    dbRead,
+		run:   runApproxSize,
+	})
+	register(&command{
+		name:  "compact",
+		short: "compact a range of keys",
+		flags: func(c *cmdContext, fs *flag.FlagSet) { c.rng.register(fs) },
+		db:    dbWrite,
+		run:   runCompact,
+	})
+	register(&command{
+		name:  "property",
+		args:  "NAME",
+		short: "print a property of the database, such as rocksdb.stats",
+		db:    dbRead,
+		run:   runProperty,
+	})
+	register(&command{
+		name:  "repair",
+		short: "try to recover the data of a corrupted database",
+		db:    dbPath,
+		run:   runRepair,
+	})
+	register(&command{
+		name:  "destroy",
+		short: "delete the database",
+		db:    dbPath,
+		run:   runDestroy,
+	})
+}
+
+func runGet(c *cmdContext, args []string) error {
+	if err := wantArgs(args, "KEY"); err != nil {
+		return err
+	}
+	key, err := c.keys.decode(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := c.db.Get(c.ro, key)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return errors.New("key not found")
+	}
+	return c.out.value(value)
+}
+
+func runPut(c *cmdContext, args []string) error {
+	if err := wantArgs(args, "KEY", "VALUE"); err != nil {
+		return err
+	}
+	key, err := c.keys.decode(args[0])
+	if err != nil {
+		return err
+	}
+	value, err := c.values.decode(args[1])
+	if err != nil {
+		return err
+	}
+	return c.db.Put(c.wo, key, value)
+}
+
+func runDelete(c *cmdContext, args []string) error {
+	if err := wantArgs(args, "KEY"); err != nil {
+		return err
+	}
+	key, err := c.keys.decode(args[0])
+	if err != nil {
+		return err
+	}
+	return c.db.Delete(c.wo, key)
+}
+
+func runScan(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	var err error
+	n := 0
+	for k, v := range c.rng.scan(c.db, c.ro, &err) {
+		if c.rng.max > 0 && n == c.rng.max {
+			break
+		}
+		if err := c.out.pair(k, v); err != nil {
+			return err
+		}
+		n++
+	}
+	return err
+}
+
+func runCount(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	var err error
+	n := 0
+	for range c.rng.scan(c.db, c.ro, &err) {
+		n++
+	}
+	if err != nil {
+		return err
+	}
+	return c.out.result("count", n)
+}
+
+func runApproxSize(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	r := c.rng.dbRange()
+	if r.Limit == nil {
+		// The range is unbounded; end it past every key of the database.
+		var err error
+		for k := range c.db.Backward(c.ro, nil, nil, &err) {
+			r.Limit = append(k, 0)
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	sizes := c.db.GetApproximateSizes([]rocksgo.Range{r})
+	if sizes == nil {
+		return rocksgo.ErrClosed
+	}
+	return c.out.result("size", sizes[0])
+}
+
+func runCompact(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	return c.db.CompactRange(c.rng.dbRange())
+}
+
+func runProperty(c *cmdContext, args []string) error {
+	if err := wantArgs(args, "NAME"); err != nil {
+		return err
+	}
+	value := c.db.PropertyValue(args[0])
+	if c.out.json {
+		return c.out.object(map[string]any{"property": args[0], "value": value})
+	}
+	_, err := c.stdout.Write([]byte(strings.TrimRight(value, "\n") + "\n"))
+	return err
+}
+
+func runRepair(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	return rocksgo.RepairDatabase(c.dbPath, c.options)
+}
+
+func runDestroy(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	return rocksgo.DestroyDatabase(c.dbPath, c.options)
+}
+
+// keyRange is the range of keys a command works on, from the flags -from,
+// -to and -prefix.
+type keyRange struct {
+	from, to, prefix string
+	// max and reverse are only set by scan.
+	max     int
+	reverse bool
+
+	// start and limit bound the range once resolved, nil meaning
+	// unbounded.
+	start, limit []byte
+}
+
+func (r *keyRange) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.from, "from", "", "start at `key`")
+	fs.StringVar(&r.to, "to", "", "stop before `key`")
+	fs.StringVar(&r.prefix, "prefix", "", "only cover the keys starting with `prefix`")
+}
+
+// resolve decodes the flags, given in f, into start and limit.
+func (r *keyRange) resolve(f format) error {
+	decode := func(s string) ([]byte, error) {
+		if s == "" {
+			return nil, nil
+		}
+		return f.decode(s)
+	}
+	var err error
+	if r.start, err = decode(r.from); err != nil {
+		return err
+	}
+	if r.limit, err = decode(r.to); err != nil {
+		return err
+	}
+	prefix, err := decode(r.prefix)
+	if err != nil || prefix == nil {
+		return err
+	}
+	// Narrow the range down to the keys with the prefix.
+	p := keys.BytesPrefixRange(prefix)
+	if r.start == nil || bytes.Compare(p.Start, r.start) > 0 {
+		r.start = p.Start
+	}
+	if p.Limit != nil && (r.limit == nil || bytes.Compare(p.Limit, r.limit) < 0) {
+		r.limit = p.Limit
+	}
+	return nil
+}
+
+func (r *keyRange) dbRange() rocksgo.Range {
+	return rocksgo.Range{Start: r.start, Limit: r.limit}
+}
+
+func (r *keyRange) scan(db *rocksgo.DB, ro *rocksgo.ReadOptions, errp *error) iter.Seq2[[]byte, []byte] {
+	if r.reverse {
+		return db.Backward(ro, r.start, r.limit, errp)
+	}
+	return db.Range(ro, r.start, r.limit, errp)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/ananclub/rocksgo"
+)
+
+func init() {
+	register(&command{
+		name:  "export",
+		short: "write the keys and values of a range of keys to a file",
+		flags: func(c *cmdContext, fs *flag.FlagSet) {
+			c.rng.register(fs)
+			c.export.register(fs, "write to `file`; - writes to the standard output")
+		},
+		db:  dbRead,
+		run: runExport,
+	})
+	register(&command{
+		name:  "import",
+		short: "put the keys and values written by export in the database",
+		flags: func(c *cmdContext, fs *flag.FlagSet) {
+			c.export.register(fs, "read from `file`; - reads the standard input")
+			fs.IntVar(&c.export.batchSize, "batch-size", 1000, "write `n` keys per batch")
+		},
+		db:  dbWrite,
+		run: runImport,
+	})
+}
+
+// exportFlags are the flags of the export and import commands.
+type exportFlags struct {
+	format    exportFormat
+	file      string
+	batchSize int
+}
+
+func (f *exportFlags) register(fs *flag.FlagSet, fileUsage string) {
+	fs.Var(&f.format, "format", "the format of the file: jsonl-base64, jsonl-escaped, csv or binary")
+	fs.StringVar(&f.file, "file", "-", fileUsage)
+}
+
+// exportFormat is a rocksgo.ExportFormat usable as a flag.
+type exportFormat rocksgo.ExportFormat
+
+func (f exportFormat) String() string { return rocksgo.ExportFormat(f).String() }
+
+func (f *exportFormat) Set(s string) error {
+	format, err := rocksgo.ParseExportFormat(s)
+	*f = exportFormat(format)
+	return err
+}
+
+func runExport(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	var f *os.File
+	w := c.stdout
+	if c.export.file != "-" {
+		var err error
+		if f, err = os.Create(c.export.file); err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	// Read a consistent view without filling the block cache with data read
+	// once.
+	snap := c.db.NewSnapshot()
+	defer c.db.ReleaseSnapshot(snap)
+	c.ro.SetSnapshot(snap)
+	c.ro.SetFillCache(false)
+	n, err := rocksgo.Export(c.db, c.ro, c.rng.dbRange(), w, rocksgo.ExportFormat(c.export.format))
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		// The pairs went to the standard output; leave it to them.
+		return nil
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return c.out.result("exported", n)
+}
+
+func runImport(c *cmdContext, args []string) error {
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	r := c.stdin
+	if c.export.file != "-" {
+		f, err := os.Open(c.export.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	n, err := rocksgo.Import(c.db, c.wo, r, rocksgo.ExportFormat(c.export.format), c.export.batchSize)
+	if err != nil {
+		return err
+	}
+	return c.out.result("imported", n)
+}
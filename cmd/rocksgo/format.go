@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ananclub/rocksgo"
+)
+
+// format is how keys and values are written on the command line and in the
+// output.
+type format int
+
+const (
+	// formatEscaped writes bytes as rocksgo.EscapeBytes does.
+	formatEscaped format = iota
+	formatHex
+	formatBase64
+)
+
+var formatNames = []string{"escaped", "hex", "base64"}
+
+func (f format) String() string {
+	return formatNames[f]
+}
+
+// Set implements flag.Value.
+func (f *format) Set(s string) error {
+	for i, name := range formatNames {
+		if s == name {
+			*f = format(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, want one of %s", s, strings.Join(formatNames, ", "))
+}
+
+func (f format) encode(b []byte) string {
+	switch f {
+	case formatHex:
+		return hex.EncodeToString(b)
+	case formatBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return rocksgo.EscapeBytes(b)
+}
+
+func (f format) decode(s string) ([]byte, error) {
+	switch f {
+	case formatHex:
+		return hex.DecodeString(s)
+	case formatBase64:
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return rocksgo.UnescapeBytes(s)
+}
@@ -0,0 +1,300 @@
+// Command rocksgo inspects and changes rocksdb databases from the command
+// line, in the manner of rocksdb's ldb tool.
+//
+// Usage:
+//
+//	rocksgo <command> -db <path> [flags] [arguments]
+//
+// The commands are:
+//
+//	get KEY          print the value of KEY
+//	put KEY VALUE    set the value of KEY
+//	delete KEY       delete KEY
+//	scan             print the keys and values of a range of keys
+//	count            print the number of keys in a range of keys
+//	approxsize       print the approximate size on disk of a range of keys
+//	compact          compact a range of keys
+//	property NAME    print a property of the database, such as rocksdb.stats
+//	repair           try to recover the data of a corrupted database
+//	destroy          delete the database
+//	sst FILE...      print the properties and entries of SST files
+//	bench            run db_bench style benchmarks against the database
+//	export           write the keys and values of a range of keys to a file
+//	import           put the keys and values written by export in the database
+//
+// The flags common to every command are:
+//
+//	-db PATH              the database directory
+//	-read-only            open the database for reading only, which can be
+//	                      done while another process has it open
+//	-create-if-missing    create the database if it does not exist
+//	-key-format FORMAT    how keys are given and printed
+//	-value-format FORMAT  how values are given and printed
+//	-output text|json     print text, or one JSON object per line
+//
+// A FORMAT is escaped, the default, where bytes other than printable ASCII
+// are written as Go escape sequences such as \x00, or hex, or base64.
+//
+// The commands working on a range of keys take the flags -from and -to,
+// which bound the range to the keys from -from up to but not including -to,
+// and -prefix, which restricts it to the keys starting with a prefix. scan
+// also takes -limit, the maximum number of keys printed, and -reverse, to
+// print them in reverse order.
+//
+// sst reads SST files on their own, and takes no -db. It prints the table
+// properties of each file, and histograms of the sizes of its live keys and
+// values; with -entries, it also prints the live entries. As when reading a
+// database, the live entries leave out deletions and the entries they
+// delete, which the entries property counts.
+//
+// bench runs the benchmarks listed by -benchmarks, among fillseq,
+// fillrandom, overwrite, readrandom, readseq, readwhilewriting,
+// multireadrandom and deleterandom, creating the database if needed. Its
+// flags set the sizes of the keys and values, the number of goroutines and
+// the Options to try, and it reports the operations per second, the
+// percentiles of the latency of the calls to the database and, after
+// writing, the write amplification, once some of the writes have been
+// flushed from the memtable.
+//
+// export writes a range of keys to the file given by -file, or to the
+// standard output, in the -format jsonl-base64, the default, jsonl-escaped,
+// csv or binary; import reads such a file, or the standard input, back into
+// a database, -batch-size keys at a time. See rocksgo.Export for the
+// formats.
+//
+// Run "rocksgo help <command>" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ananclub/rocksgo"
+)
+
+// command is a subcommand of rocksgo.
+type command struct {
+	name string
+	// args describes the arguments of the command in its usage line.
+	args  string
+	short string
+	// flags registers the flags of the command, besides the common ones,
+	// on fs.
+	flags func(c *cmdContext, fs *flag.FlagSet)
+	// db is how the command needs the database opened, if at all.
+	db  dbMode
+	run func(c *cmdContext, args []string) error
+}
+
+type dbMode int
+
+const (
+	// dbNone is for the commands not working on a database.
+	dbNone dbMode = iota
+	// dbPath is for the commands given the path of a database, but not
+	// opening it. They all change the database, as dbWrite commands do.
+	dbPath
+	dbRead
+	dbWrite
+)
+
+var commands []*command
+
+func register(c *command) {
+	commands = append(commands, c)
+}
+
+func lookup(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// cmdContext holds the flags of a command run, and the database it works
+// on.
+type cmdContext struct {
+	dbPath          string
+	readOnly        bool
+	createIfMissing bool
+	keys, values    format
+	json            bool
+	rng             keyRange
+	sst             sstFlags
+	bench           benchFlags
+	export          exportFlags
+
+	stdin  io.Reader
+	stdout io.Writer
+	out    *output
+
+	options *rocksgo.Options
+	db      *rocksgo.DB
+	ro      *rocksgo.ReadOptions
+	wo      *rocksgo.WriteOptions
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "rocksgo: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned when the command line is wrong, once the usage has
+// been printed.
+var errUsage = errors.New("invalid command line")
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		usage(stderr)
+		return errUsage
+	}
+	name, args := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(args) == 0 {
+			usage(stdout)
+			return nil
+		}
+		cmd := lookup(args[0])
+		if cmd == nil {
+			return fmt.Errorf("unknown command %q", args[0])
+		}
+		fs, _ := newFlagSet(cmd, stdout)
+		fs.Usage()
+		return nil
+	}
+	cmd := lookup(name)
+	if cmd == nil {
+		usage(stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+	fs, c := newFlagSet(cmd, stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c.stdin, c.stdout = stdin, stdout
+	c.out = &output{w: stdout, json: c.json, keys: c.keys, values: c.values}
+	if c.dbPath == "" && cmd.db != dbNone {
+		return errors.New("-db is required")
+	}
+	if err := c.rng.resolve(c.keys); err != nil {
+		return err
+	}
+	defer c.close()
+	if err := c.open(cmd.db); err != nil {
+		return err
+	}
+	return cmd.run(c, fs.Args())
+}
+
+func newFlagSet(cmd *command, w io.Writer) (*flag.FlagSet, *cmdContext) {
+	c := &cmdContext{}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.StringVar(&c.dbPath, "db", "", "the database `directory`")
+	fs.BoolVar(&c.readOnly, "read-only", false, "open the database for reading only")
+	fs.BoolVar(&c.createIfMissing, "create-if-missing", false, "create the database if it does not exist")
+	fs.Var(&c.keys, "key-format", "how keys are given and printed: escaped, hex or base64")
+	fs.Var(&c.values, "value-format", "how values are given and printed: escaped, hex or base64")
+	outFormat := outputFlag{&c.json}
+	fs.Var(outFormat, "output", "print text, or one JSON object per line: text or json")
+	if cmd.flags != nil {
+		cmd.flags(c, fs)
+	}
+	fs.Usage = func() {
+		db := "-db <path> "
+		if cmd.db == dbNone {
+			db = ""
+		}
+		fmt.Fprintf(fs.Output(), "usage: rocksgo %s %s[flags] %s\n\n%s.\n\nflags:\n",
+			cmd.name, db, cmd.args, cmd.short)
+		fs.PrintDefaults()
+	}
+	return fs, c
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: rocksgo <command> -db <path> [flags] [arguments]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.short)
+	}
+	fmt.Fprintf(w, "\nRun \"rocksgo help <command>\" for the flags of a command.\n")
+}
+
+// open opens the database as mode requires.
+func (c *cmdContext) open(mode dbMode) error {
+	c.options = rocksgo.NewOptions()
+	if (mode == dbPath || mode == dbWrite) && c.readOnly {
+		return errors.New("the command writes to the database, and cannot be run with -read-only")
+	}
+	if mode == dbNone || mode == dbPath {
+		return nil
+	}
+	c.options.SetCreateIfMissing(c.createIfMissing)
+	c.ro = rocksgo.NewReadOptions()
+	c.wo = rocksgo.NewWriteOptions()
+	var err error
+	if c.readOnly {
+		c.db, err = rocksgo.OpenForReadOnly(c.dbPath, c.options, false)
+	} else {
+		c.db, err = rocksgo.Open(c.dbPath, c.options)
+	}
+	return err
+}
+
+func (c *cmdContext) close() {
+	if c.db != nil {
+		c.db.Close()
+	}
+	if c.ro != nil {
+		c.ro.Close()
+		c.wo.Close()
+	}
+	if c.options != nil {
+		c.options.Close()
+	}
+}
+
+// outputFlag sets whether the output is JSON.
+type outputFlag struct {
+	json *bool
+}
+
+func (f outputFlag) String() string {
+	if f.json != nil && *f.json {
+		return "json"
+	}
+	return "text"
+}
+
+func (f outputFlag) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "text":
+		*f.json = false
+	case "json":
+		*f.json = true
+	default:
+		return fmt.Errorf("unknown output %q, want text or json", s)
+	}
+	return nil
+}
+
+// wantArgs checks that a command was given the arguments named.
+func wantArgs(args []string, names ...string) error {
+	if len(args) == len(names) {
+		return nil
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("the command takes no arguments, got %q", args)
+	}
+	return fmt.Errorf("want the arguments %s, got %q", strings.Join(names, " "), args)
+}
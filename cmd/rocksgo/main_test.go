@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormats(t *testing.T) {
+	data := []byte("k\x00\xfe=v")
+	for _, f := range []format{formatEscaped, formatHex, formatBase64} {
+		s := f.encode(data)
+		got, err := f.decode(s)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%v: decode(%q) = %q, %v, want %q", f, s, got, err, data)
+		}
+	}
+}
+
+func TestKeyRange(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, prefix string
+		start, limit     string
+	}{
+		{"", "", "", "", ""},
+		{"b", "d", "", "b", "d"},
+		{"", "", "ab", "ab", "ac"},
+		{"abc", "", "ab", "abc", "ac"},
+		{"a", "abc", "ab", "ab", "abc"},
+	} {
+		r := keyRange{from: tc.from, to: tc.to, prefix: tc.prefix}
+		if err := r.resolve(formatEscaped); err != nil {
+			t.Fatal(err)
+		}
+		if string(r.start) != tc.start || string(r.limit) != tc.limit {
+			t.Errorf("from %q to %q with prefix %q: got [%q, %q), want [%q, %q)",
+				tc.from, tc.to, tc.prefix, r.start, r.limit, tc.start, tc.limit)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	for _, size := range []int{0, 1, 2, 3, 4, 100} {
+		h.add(size)
+	}
+	s := h.summary()
+	if s.Count != 6 || s.Min != 0 || s.Max != 100 || s.Mean != 110.0/6 {
+		t.Errorf("summary = %+v", s)
+	}
+	want := []histogramBucket{{0, 1, 1}, {1, 2, 1}, {2, 4, 2}, {4, 8, 1}, {64, 128, 1}}
+	if fmt.Sprint(s.Buckets) != fmt.Sprint(want) {
+		t.Errorf("buckets = %v, want %v", s.Buckets, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var d []time.Duration
+	for i := 1; i <= 1000; i++ {
+		d = append(d, time.Duration(i))
+	}
+	for p, want := range map[float64]time.Duration{50: 500, 99: 990, 99.9: 999, 100: 1000, 0: 1} {
+		if got := percentile(d, p); got != want {
+			t.Errorf("percentile %v = %v, want %v", p, got, want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no durations = %v", got)
+	}
+}
+
+func TestBench(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "db")
+	out := runCmd(t, "bench", "-db", db, "-num", "1000", "-threads", "2", "-batch-size", "4", "-output", "json",
+		"-benchmarks", strings.Join(benchmarkNames(), ","))
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != len(benchmarks) {
+		t.Fatalf("want a result per benchmark, got:\n%s", out)
+	}
+	for i, line := range lines {
+		var res benchResult
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		if res.Benchmark != benchmarks[i].name || res.Ops != 1000 || res.OpsPerSec <= 0 {
+			t.Errorf("unexpected result %s", line)
+		}
+		if res.Benchmark == "readrandom" && res.Found == 0 {
+			t.Errorf("readrandom found none of the keys written: %s", line)
+		}
+		if res.WriteAmp != nil {
+			t.Errorf("the write amplification was reported before any flush: %s", line)
+		}
+	}
+	if _, err := os.Stat(db); err != nil {
+		t.Errorf("bench did not create the database: %v", err)
+	}
+}
+
+func TestBenchWriteAmp(t *testing.T) {
+	// 20000 keys of 116 bytes are about 35 memtables of 64 KiB, flushed
+	// without compression, so that every byte written reaches an SST file at
+	// least once.
+	db := filepath.Join(t.TempDir(), "db")
+	out := runCmd(t, "bench", "-db", db, "-num", "20000", "-write-buffer-size", "65536", "-compression", "none",
+		"-output", "json", "-benchmarks", "fillseq")
+	var res benchResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatalf("%s: %v", out, err)
+	}
+	if res.WriteAmp == nil || *res.WriteAmp < 1 {
+		t.Errorf("fillseq reported a write amplification of %v, want at least 1: %s", res.WriteAmp, out)
+	}
+}
+
+func runCmd(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if err := run(args, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatalf("rocksgo %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "db")
+	runCmd(t, "put", "-db", db, "-create-if-missing", "a", "1")
+	runCmd(t, "put", "-db", db, "-value-format", "hex", `b\x00`, "ff00")
+	runCmd(t, "put", "-db", db, "c", "3")
+	runCmd(t, "delete", "-db", db, "c")
+
+	if got := runCmd(t, "get", "-db", db, "a"); got != "1\n" {
+		t.Errorf("get = %q", got)
+	}
+	if got := runCmd(t, "scan", "-db", db, "-read-only"); got != "a ==> 1\nb\\x00 ==> \\xff\\x00\n" {
+		t.Errorf("scan = %q", got)
+	}
+	if got := runCmd(t, "scan", "-db", db, "-reverse", "-limit", "1", "-output", "json", "-key-format", "hex"); got != `{"key":"6200","value":"\\xff\\x00"}`+"\n" {
+		t.Errorf("scan -reverse -limit 1 = %q", got)
+	}
+	if got := runCmd(t, "count", "-db", db, "-prefix", "b"); got != "1\n" {
+		t.Errorf("count -prefix b = %q", got)
+	}
+	runCmd(t, "compact", "-db", db)
+	if got := runCmd(t, "property", "-db", db, "rocksdb.estimate-num-keys"); got != "2\n" {
+		t.Errorf("property = %q", got)
+	}
+
+	files, err := filepath.Glob(filepath.Join(db, "*.sst"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("want a single SST file after compact, got %v, %v", files, err)
+	}
+	if got := runCmd(t, "sst", "-output", "json", files[0]); !strings.Contains(got, `"entries":2`) ||
+		!strings.Contains(got, `"smallest_live_key":"a"`) || !strings.Contains(got, `"live_key_sizes":{"count":2`) {
+		t.Errorf("sst = %s", got)
+	}
+	if got := runCmd(t, "sst", "-entries", "-limit", "1", files[0]); !strings.HasPrefix(got, "a ==> 1\n") {
+		t.Errorf("sst -entries -limit 1 = %s", got)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"put", "-db", db, "-read-only", "a", "2"}, nil, &stdout, &stderr); err == nil {
+		t.Errorf("put succeeded with -read-only")
+	}
+	if err := run([]string{"get", "-db", db, "c"}, nil, &stdout, &stderr); err == nil {
+		t.Errorf("get of a deleted key succeeded")
+	}
+	for _, cmd := range []string{"destroy", "repair"} {
+		if err := run([]string{cmd, "-db", db, "-read-only"}, nil, &stdout, &stderr); err == nil {
+			t.Errorf("%s succeeded with -read-only", cmd)
+		}
+	}
+	if got := runCmd(t, "get", "-db", db, "-read-only", "a"); got != "1\n" {
+		t.Errorf("get after destroy -read-only = %q, want the database left in place", got)
+	}
+	runCmd(t, "destroy", "-db", db)
+}
+
+func TestExportImport(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	runCmd(t, "put", "-db", src, "-create-if-missing", "a", "1")
+	runCmd(t, "put", "-db", src, `b\x00`, `x,"y"`)
+	runCmd(t, "put", "-db", src, "c", "3")
+
+	if got := runCmd(t, "export", "-db", src, "-format", "csv", "-to", "c"); got != "key,value\na,1\nb\\x00,\"x,\"\"y\"\"\"\n" {
+		t.Errorf("export -format csv = %q", got)
+	}
+	file := filepath.Join(dir, "export.bin")
+	if got := runCmd(t, "export", "-db", src, "-format", "binary", "-file", file); got != "3\n" {
+		t.Errorf("export -file = %q", got)
+	}
+	if got := runCmd(t, "import", "-db", dst, "-create-if-missing", "-format", "binary", "-batch-size", "2", "-file", file); got != "3\n" {
+		t.Errorf("import -file = %q", got)
+	}
+	if got, want := runCmd(t, "scan", "-db", dst), runCmd(t, "scan", "-db", src); got != want {
+		t.Errorf("scan after import = %q, want %q", got, want)
+	}
+
+	var stdout, stderr bytes.Buffer
+	in := strings.NewReader(`{"key":"ZA==","value":"NA=="}` + "\n")
+	if err := run([]string{"import", "-db", dst}, in, &stdout, &stderr); err != nil || stdout.String() != "1\n" {
+		t.Errorf("import from stdin = %q, %v", stdout.String(), err)
+	}
+	if got := runCmd(t, "get", "-db", dst, "d"); got != "4\n" {
+		t.Errorf("get of an imported key = %q", got)
+	}
+	if err := run([]string{"export", "-db", dst, "-format", "xml"}, nil, &stdout, &stderr); err == nil {
+		t.Errorf("export accepted an unknown format")
+	}
+}
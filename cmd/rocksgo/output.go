@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// output prints the results of a command, as text or as JSON lines.
+type output struct {
+	w            io.Writer
+	json         bool
+	keys, values format
+}
+
+// pair prints a key and its value, as "key ==> value" or as a JSON object
+// with the fields key and value.
+func (o *output) pair(key, value []byte) error {
+	k, v := o.keys.encode(key), o.values.encode(value)
+	if o.json {
+		return o.object(map[string]any{"key": k, "value": v})
+	}
+	_, err := fmt.Fprintf(o.w, "%s ==> %s\n", k, v)
+	return err
+}
+
+// value prints a value alone, or as a JSON object with the field value.
+func (o *output) value(value []byte) error {
+	v := o.values.encode(value)
+	if o.json {
+		return o.object(map[string]any{"value": v})
+	}
+	_, err := fmt.Fprintln(o.w, v)
+	return err
+}
+
+// result prints the result of a command, alone or as the field name of a
+// JSON object.
+func (o *output) result(name string, v any) error {
+	if o.json {
+		return o.object(map[string]any{name: v})
+	}
+	_, err := fmt.Fprintln(o.w, v)
+	return err
+}
+
+// object prints v as a line of JSON.
+func (o *output) object(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = o.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+
+	"github.com/ananclub/rocksgo"
+)
+
+func init() {
+	register(&command{
+		name:  "sst",
+		args:  "FILE...",
+		short: "print the properties and entries of SST files",
+		flags: func(c *cmdContext, fs *flag.FlagSet) {
+			fs.BoolVar(&c.sst.entries, "entries", false, "print the live entries of the files")
+			fs.IntVar(&c.sst.max, "limit", 0, "print at most `n` live entries of each file; 0 prints them all")
+			fs.BoolVar(&c.sst.verify, "verify", false, "verify the checksums of the files")
+		},
+		run: runSst,
+	})
+}
+
+// sstFlags are the flags of the sst command.
+type sstFlags struct {
+	entries bool
+	max     int
+	verify  bool
+}
+
+func runSst(c *cmdContext, args []string) error {
+	if len(args) == 0 {
+		return errors.New("want the arguments FILE...")
+	}
+	for _, path := range args {
+		if err := dumpSst(c, path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func dumpSst(c *cmdContext, path string) error {
+	r := rocksgo.NewSstFileReader(c.options)
+	defer r.Close()
+	if err := r.Open(path); err != nil {
+		return err
+	}
+	if c.sst.verify {
+		if err := r.VerifyChecksum(); err != nil {
+			return err
+		}
+	}
+	props, err := r.Properties()
+	if err != nil {
+		return err
+	}
+
+	ro := rocksgo.NewReadOptions()
+	defer ro.Close()
+	ro.SetFillCache(false)
+	it := r.NewIterator(ro)
+	defer it.Close()
+	var keySizes, valueSizes histogram
+	n := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		k, v := it.Key(), it.Value()
+		keySizes.add(len(k))
+		valueSizes.add(len(v))
+		if c.sst.entries && (c.sst.max <= 0 || n < c.sst.max) {
+			if err := c.out.pair(k, v); err != nil {
+				return err
+			}
+		}
+		n++
+	}
+	if err := it.GetError(); err != nil {
+		return err
+	}
+
+	if c.out.json {
+		return c.out.object(map[string]any{
+			"file":        path,
+			"properties":  sstProperties(c, props),
+			"live_key_sizes":   keySizes.summary(),
+			"live_value_sizes": valueSizes.summary(),
+		})
+	}
+	fmt.Fprintf(c.stdout, "%s:\n", path)
+	for _, p := range sstPropertyList(c, props) {
+		fmt.Fprintf(c.stdout, "  %-22s %v\n", p.name+":", p.value)
+	}
+	keySizes.print(c.stdout, "live key sizes")
+	valueSizes.print(c.stdout, "live value sizes")
+	return nil
+}
+
+type sstProperty struct {
+	name  string
+	value any
+}
+
+// sstPropertyList lists the properties of a file in the order printed.
+func sstPropertyList(c *cmdContext, p rocksgo.TableProperties) []sstProperty {
+	keyOrNone := func(k []byte) any {
+		if k == nil {
+			return nil
+		}
+		return c.keys.encode(k)
+	}
+	timeOrNone := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return []sstProperty{
+		{"entries", p.NumEntries},
+		{"deletions", p.NumDeletions},
+		{"merge_operands", p.NumMergeOperands},
+		{"range_deletions", p.NumRangeDeletions},
+		{"raw_key_size", p.RawKeySize},
+		{"raw_value_size", p.RawValueSize},
+		{"data_size", p.DataSize},
+		{"index_size", p.IndexSize},
+		{"filter_size", p.FilterSize},
+		{"data_blocks", p.NumDataBlocks},
+		{"format_version", p.FormatVersion},
+		{"compression", p.CompressionName},
+		{"comparator", p.ComparatorName},
+		{"filter_policy", p.FilterPolicyName},
+		{"column_family", p.ColumnFamilyName},
+		{"creation_time", timeOrNone(p.CreationTime)},
+		{"oldest_key_time", timeOrNone(p.OldestKeyTime)},
+		{"smallest_live_key", keyOrNone(p.SmallestKey)},
+		{"largest_live_key", keyOrNone(p.LargestKey)},
+	}
+}
+
+func sstProperties(c *cmdContext, p rocksgo.TableProperties) map[string]any {
+	m := make(map[string]any)
+	for _, p := range sstPropertyList(c, p) {
+		m[p.name] = p.value
+	}
+	return m
+}
+
+// histogram counts sizes in buckets of powers of two: bucket 0 holds the
+// zero sizes, and bucket i > 0 the sizes from 1<<(i-1) up to but not
+// including 1<<i.
+type histogram struct {
+	count    uint64
+	sum      uint64
+	min, max int
+	buckets  [65]uint64
+}
+
+func (h *histogram) add(size int) {
+	if h.count == 0 || size < h.min {
+		h.min = size
+	}
+	if size > h.max {
+		h.max = size
+	}
+	h.count++
+	h.sum += uint64(size)
+	h.buckets[bits.Len(uint(size))]++
+}
+
+func (h *histogram) mean() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.count)
+}
+
+// bucketRange returns the sizes bucket i holds, from lo up to but not
+// including hi.
+func bucketRange(i int) (lo, hi uint64) {
+	if i == 0 {
+		return 0, 1
+	}
+	return 1 << (i - 1), 1 << i
+}
+
+type histogramBucket struct {
+	Min   uint64 `json:"min"`
+	Limit uint64 `json:"limit"`
+	Count uint64 `json:"count"`
+}
+
+type histogramSummary struct {
+	Count   uint64            `json:"count"`
+	Min     int               `json:"min"`
+	Max     int               `json:"max"`
+	Mean    float64           `json:"mean"`
+	Buckets []histogramBucket `json:"buckets"`
+}
+
+// summary returns the histogram with its empty buckets left out.
+func (h *histogram) summary() histogramSummary {
+	s := histogramSummary{Count: h.count, Min: h.min, Max: h.max, Mean: h.mean(), Buckets: []histogramBucket{}}
+	for i, n := range h.buckets {
+		if n != 0 {
+			lo, hi := bucketRange(i)
+			s.Buckets = append(s.Buckets, histogramBucket{lo, hi, n})
+		}
+	}
+	return s
+}
+
+func (h *histogram) print(w io.Writer, title string) {
+	s := h.summary()
+	fmt.Fprintf(w, "  %s: count %d, min %d, max %d, mean %.1f\n", title, s.Count, s.Min, s.Max, s.Mean)
+	for _, b := range s.Buckets {
+		fmt.Fprintf(w, "    [%d, %d) %10d %6.2f%%\n", b.Min, b.Limit, b.Count, 100*float64(b.Count)/float64(s.Count))
+	}
+}
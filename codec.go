@@ -0,0 +1,131 @@
+package rocksgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// KeyCodec converts the keys of a Collection to and from bytes. So that
+// the range scans of a Collection make sense, the encoding should sort in
+// the same order as the keys themselves.
+type KeyCodec[K any] interface {
+	// AppendKey appends the encoding of k to dst and returns the result.
+	AppendKey(dst []byte, k K) []byte
+	// DecodeKey decodes a key encoded by AppendKey.
+	DecodeKey(b []byte) (K, error)
+}
+
+// ValueCodec converts the values of a Collection to and from bytes.
+type ValueCodec[V any] interface {
+	EncodeValue(v V) ([]byte, error)
+	// DecodeValue decodes a value encoded by EncodeValue. b belongs to the
+	// caller, and may be kept in the value returned.
+	DecodeValue(b []byte) (V, error)
+}
+
+// StringCodec encodes strings as their bytes. It is both a KeyCodec and a
+// ValueCodec.
+type StringCodec struct{}
+
+func (StringCodec) AppendKey(dst []byte, k string) []byte { return append(dst, k...) }
+func (StringCodec) DecodeKey(b []byte) (string, error)    { return string(b), nil }
+func (StringCodec) EncodeValue(v string) ([]byte, error)  { return []byte(v), nil }
+func (StringCodec) DecodeValue(b []byte) (string, error)  { return string(b), nil }
+
+// BytesCodec stores byte slices as they are. It is both a KeyCodec and a
+// ValueCodec.
+type BytesCodec struct{}
+
+func (BytesCodec) AppendKey(dst []byte, k []byte) []byte { return append(dst, k...) }
+func (BytesCodec) DecodeKey(b []byte) ([]byte, error)    { return b, nil }
+func (BytesCodec) EncodeValue(v []byte) ([]byte, error)  { return v, nil }
+func (BytesCodec) DecodeValue(b []byte) ([]byte, error)  { return b, nil }
+
+// Uint64Codec encodes uint64s as 8 big-endian bytes, which sort in numeric
+// order. It is both a KeyCodec and a ValueCodec.
+type Uint64Codec struct{}
+
+func (Uint64Codec) AppendKey(dst []byte, k uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, k)
+}
+
+func (Uint64Codec) DecodeKey(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("rocksgo: uint64 encoding of %d bytes, want 8", len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (c Uint64Codec) EncodeValue(v uint64) ([]byte, error) { return c.AppendKey(nil, v), nil }
+func (c Uint64Codec) DecodeValue(b []byte) (uint64, error) { return c.DecodeKey(b) }
+
+// Int64Codec encodes int64s as 8 big-endian bytes with the sign bit
+// flipped, so that negative numbers sort before positive ones. It is both a
+// KeyCodec and a ValueCodec.
+type Int64Codec struct{}
+
+func (Int64Codec) AppendKey(dst []byte, k int64) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(k)^1<<63)
+}
+
+func (Int64Codec) DecodeKey(b []byte) (int64, error) {
+	u, err := Uint64Codec{}.DecodeKey(b)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u ^ 1<<63), nil
+}
+
+func (c Int64Codec) EncodeValue(v int64) ([]byte, error) { return c.AppendKey(nil, v), nil }
+func (c Int64Codec) DecodeValue(b []byte) (int64, error) { return c.DecodeKey(b) }
+
+// Uint32Codec encodes uint32s as 4 big-endian bytes, which sort in numeric
+// order. It is both a KeyCodec and a ValueCodec.
+type Uint32Codec struct{}
+
+func (Uint32Codec) AppendKey(dst []byte, k uint32) []byte {
+	return binary.BigEndian.AppendUint32(dst, k)
+}
+
+func (Uint32Codec) DecodeKey(b []byte) (uint32, error) {
+	if len(b) != 4 {
+		return 0, fmt.Errorf("rocksgo: uint32 encoding of %d bytes, want 4", len(b))
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (c Uint32Codec) EncodeValue(v uint32) ([]byte, error) { return c.AppendKey(nil, v), nil }
+func (c Uint32Codec) DecodeValue(b []byte) (uint32, error) { return c.DecodeKey(b) }
+
+// JSONCodec encodes values with encoding/json.
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) EncodeValue(v V) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec[V]) DecodeValue(b []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// GobCodec encodes values with encoding/gob. Each value is encoded on its
+// own, type information included, so GobCodec suits values of structured
+// types more than small scalars.
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) EncodeValue(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[V]) DecodeValue(b []byte) (V, error) {
+	var v V
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v, err
+}
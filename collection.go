@@ -0,0 +1,135 @@
+package rocksgo
+
+import (
+	"iter"
+
+	"github.com/ananclub/rocksgo/internal/scanerr"
+)
+
+// Collection is a typed view of the keys of a DB starting with a prefix,
+// converting keys of type K and values of type V to and from bytes with a
+// KeyCodec and a ValueCodec.
+//
+// Collections with different prefixes may share a DB, as long as no prefix
+// is a prefix of another.
+type Collection[K, V any] struct {
+	db     *DB
+	prefix []byte
+	keys   KeyCodec[K]
+	values ValueCodec[V]
+}
+
+// NewCollection returns the Collection of the keys of db starting with
+// prefix.
+func NewCollection[K, V any](db *DB, prefix []byte, keys KeyCodec[K], values ValueCodec[V]) *Collection[K, V] {
+	return &Collection[K, V]{
+		db:     db,
+		prefix: append([]byte(nil), prefix...),
+		keys:   keys,
+		values: values,
+	}
+}
+
+// key returns the key k is stored under in the DB.
+func (c *Collection[K, V]) key(k K) []byte {
+	return c.keys.AppendKey(append([]byte(nil), c.prefix...), k)
+}
+
+// Get returns the value stored for k, and whether there is one.
+func (c *Collection[K, V]) Get(ro *ReadOptions, k K) (V, bool, error) {
+	var v V
+	data, err := c.db.Get(ro, c.key(k))
+	if err != nil || data == nil {
+		return v, false, err
+	}
+	v, err = c.values.DecodeValue(data)
+	if err != nil {
+		return v, false, err
+	}
+	return v, true, nil
+}
+
+// Put stores v for k.
+func (c *Collection[K, V]) Put(wo *WriteOptions, k K, v V) error {
+	data, err := c.values.EncodeValue(v)
+	if err != nil {
+		return err
+	}
+	return c.db.Put(wo, c.key(k), data)
+}
+
+// Delete removes the value stored for k, if any.
+func (c *Collection[K, V]) Delete(wo *WriteOptions, k K) error {
+	return c.db.Delete(wo, c.key(k))
+}
+
+// All returns the entries of the Collection, in the order of their encoded
+// keys. Errors, including those decoding entries, are reported through errp
+// as for DB.All.
+func (c *Collection[K, V]) All(ro *ReadOptions, errp *error) iter.Seq2[K, V] {
+	return c.scan(errp, func(err *error) iter.Seq2[[]byte, []byte] {
+		return c.db.Prefix(ro, c.prefix, err)
+	})
+}
+
+// Range returns the entries of the Collection from the key start up to but
+// not including the key limit, in the order of their encoded keys. Errors,
+// including those decoding entries, are reported through errp as for
+// DB.All.
+func (c *Collection[K, V]) Range(ro *ReadOptions, start, limit K, errp *error) iter.Seq2[K, V] {
+	return c.scan(errp, func(err *error) iter.Seq2[[]byte, []byte] {
+		return c.db.Range(ro, c.key(start), c.key(limit), err)
+	})
+}
+
+func (c *Collection[K, V]) scan(errp *error, seq func(*error) iter.Seq2[[]byte, []byte]) iter.Seq2[K, V] {
+	scanerr.Check(errp)
+	return func(yield func(K, V) bool) {
+		var scanErr, decodeErr error
+		for data, value := range seq(&scanErr) {
+			var k K
+			var v V
+			if k, decodeErr = c.keys.DecodeKey(data[len(c.prefix):]); decodeErr != nil {
+				break
+			}
+			if v, decodeErr = c.values.DecodeValue(value); decodeErr != nil {
+				break
+			}
+			if !yield(k, v) {
+				break
+			}
+		}
+		if decodeErr != nil {
+			scanErr = decodeErr
+		}
+		*errp = scanErr
+	}
+}
+
+// Batch returns a view of wb staging updates to the Collection, to be
+// written atomically with DB.Write along with any other update in wb.
+func (c *Collection[K, V]) Batch(wb *WriteBatch) CollectionBatch[K, V] {
+	return CollectionBatch[K, V]{c: c, wb: wb}
+}
+
+// CollectionBatch stages typed updates to a Collection in a WriteBatch. It
+// is returned by Collection.Batch.
+type CollectionBatch[K, V any] struct {
+	c  *Collection[K, V]
+	wb *WriteBatch
+}
+
+// Put stages storing v for k.
+func (b CollectionBatch[K, V]) Put(k K, v V) error {
+	data, err := b.c.values.EncodeValue(v)
+	if err != nil {
+		return err
+	}
+	b.wb.Put(b.c.key(k), data)
+	return nil
+}
+
+// Delete stages removing the value stored for k.
+func (b CollectionBatch[K, V]) Delete(k K) {
+	b.wb.Delete(b.c.key(k))
+}
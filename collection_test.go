@@ -0,0 +1,123 @@
+package rocksgo
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestIntCodecsSortNumerically(t *testing.T) {
+	i64 := func(a, b int64) bool {
+		ea, eb := Int64Codec{}.AppendKey(nil, a), Int64Codec{}.AppendKey(nil, b)
+		got, err := Int64Codec{}.DecodeKey(ea)
+		return err == nil && got == a && (bytes.Compare(ea, eb) < 0) == (a < b)
+	}
+	if err := quick.Check(i64, nil); err != nil {
+		t.Error(err)
+	}
+	u64 := func(a, b uint64) bool {
+		ea, eb := Uint64Codec{}.AppendKey(nil, a), Uint64Codec{}.AppendKey(nil, b)
+		got, err := Uint64Codec{}.DecodeKey(ea)
+		return err == nil && got == a && (bytes.Compare(ea, eb) < 0) == (a < b)
+	}
+	if err := quick.Check(u64, nil); err != nil {
+		t.Error(err)
+	}
+	u32 := func(a, b uint32) bool {
+		ea, eb := Uint32Codec{}.AppendKey(nil, a), Uint32Codec{}.AppendKey(nil, b)
+		got, err := Uint32Codec{}.DecodeKey(ea)
+		return err == nil && got == a && (bytes.Compare(ea, eb) < 0) == (a < b)
+	}
+	if err := quick.Check(u32, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := (Uint64Codec{}).DecodeKey([]byte{1, 2}); err == nil {
+		t.Errorf("decoding a short uint64 should fail")
+	}
+}
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func TestValueCodecs(t *testing.T) {
+	u := user{"ann", 42}
+	for _, c := range []ValueCodec[user]{JSONCodec[user]{}, GobCodec[user]{}} {
+		data, err := c.EncodeValue(u)
+		if err != nil {
+			t.Fatalf("%T: encoding failed: %v", c, err)
+		}
+		if got, err := c.DecodeValue(data); err != nil || got != u {
+			t.Errorf("%T: decoded %+v, %v; want %+v", c, got, err, u)
+		}
+	}
+}
+
+func TestCollection(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	users := NewCollection[int64, user](db, []byte("u/"), Int64Codec{}, JSONCodec[user]{})
+	names := NewCollection[string, string](db, []byte("n/"), StringCodec{}, StringCodec{})
+
+	for _, id := range []int64{3, -1, 2, 10} {
+		if err := users.Put(wo, id, user{Name: "user", Age: int(id)}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := names.Put(wo, "x", "y"); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if u, ok, err := users.Get(ro, 2); err != nil || !ok || u.Age != 2 {
+		t.Errorf("Get(2) = %+v, %v, %v", u, ok, err)
+	}
+	if _, ok, err := users.Get(ro, 4); err != nil || ok {
+		t.Errorf("Get of a missing key = %v, %v", ok, err)
+	}
+
+	var err error
+	var ids []int64
+	for id, u := range users.All(ro, &err) {
+		if u.Age != int(id) {
+			t.Errorf("user %d has age %d", id, u.Age)
+		}
+		ids = append(ids, id)
+	}
+	if err != nil || len(ids) != 4 || ids[0] != -1 || ids[3] != 10 {
+		t.Errorf("All yielded %v, %v; want [-1 2 3 10]", ids, err)
+	}
+	ids = ids[:0]
+	for id := range users.Range(ro, 0, 10, &err) {
+		ids = append(ids, id)
+	}
+	if err != nil || len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("Range(0, 10) yielded %v, %v; want [2 3]", ids, err)
+	}
+
+	wb := NewWriteBatch()
+	defer wb.Close()
+	users.Batch(wb).Delete(-1)
+	if err := users.Batch(wb).Put(7, user{Age: 7}); err != nil {
+		t.Fatalf("staging a Put failed: %v", err)
+	}
+	if err := names.Batch(wb).Put("a", "b"); err != nil {
+		t.Fatalf("staging a Put failed: %v", err)
+	}
+	if err := db.Write(wo, wb); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, ok, _ := users.Get(ro, -1); ok {
+		t.Errorf("the staged Delete was not written")
+	}
+	if v, ok, _ := names.Get(ro, "a"); !ok || v != "b" {
+		t.Errorf("the staged Put was not written")
+	}
+
+	if err := db.Put(wo, []byte("u/bad"), []byte("{}")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	for range users.All(ro, &err) {
+	}
+	if err == nil {
+		t.Errorf("a key that cannot be decoded should fail the scan")
+	}
+}
@@ -0,0 +1,125 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+// A call into rocksdb cannot be interrupted once it has started, so the
+// Context variants of the DB methods below check for cancellation before
+// each call, and hand a deadline of the Context to rocksdb as the deadline
+// and I/O timeout of reads, which rocksdb enforces itself where the
+// underlying table and file system support it.
+
+// GetContext is like Get, but gives up with ctx.Err() if ctx is done before
+// the read starts, and with context.DeadlineExceeded if the deadline of ctx
+// passes during the read.
+func (db *DB) GetContext(ctx context.Context, ro *ReadOptions, key []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline, ioTimeout, ok := readDeadline(ctx)
+	if !ok {
+		return db.Get(ro, key)
+	}
+	if err := db.acquire(); err != nil {
+		return nil, err
+	}
+	defer db.release()
+
+	var errStr *C.char
+	var vallen C.size_t
+	value := C.rocksgo_get_deadline(db.Ldb, ro.Opt, deadline, ioTimeout,
+		byteSliceToChar(key), C.size_t(len(key)), &vallen, &errStr)
+
+	if err := statusError(errStr); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	defer C.rocksdb_free(unsafe.Pointer(value))
+	return C.GoBytes(unsafe.Pointer(value), C.int(vallen)), nil
+}
+
+// PutContext is like Put, but gives up with ctx.Err() if ctx is done before
+// the write starts. A write that has started always runs to completion.
+func (db *DB) PutContext(ctx context.Context, wo *WriteOptions, key, value []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.Put(wo, key, value)
+}
+
+// WriteContext is like Write, but gives up with ctx.Err() if ctx is done
+// before the batch is written. A write that has started always runs to
+// completion.
+func (db *DB) WriteContext(ctx context.Context, wo *WriteOptions, w *WriteBatch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.Write(wo, w)
+}
+
+// NewIteratorContext is like NewIterator, but the Iterator returned stops
+// as soon as ctx is done: every Seek, Next and Prev first checks ctx, and
+// once it is done the Iterator becomes invalid and its GetError returns
+// ctx.Err(). The deadline of ctx, if any, also bounds each step inside
+// rocksdb, with GetError then returning context.DeadlineExceeded.
+//
+// If ctx is already done, the Iterator returned is invalid.
+func (db *DB) NewIteratorContext(ctx context.Context, ro *ReadOptions) *Iterator {
+	if err := ctx.Err(); err != nil {
+		return &Iterator{err: err}
+	}
+	deadline, ioTimeout, ok := readDeadline(ctx)
+	if !ok {
+		it := db.NewIterator(ro)
+		it.ctx = ctx
+		return it
+	}
+	if err := db.acquire(); err != nil {
+		return &Iterator{err: err}
+	}
+	it := &Iterator{
+		Iter: C.rocksgo_create_iterator_deadline(db.Ldb, ro.Opt, deadline, ioTimeout),
+		db:   db,
+		ctx:  ctx,
+	}
+	it.leak = trackResource(it, "Iterator", db)
+	return it
+}
+
+// readDeadline returns the deadline of ctx as rocksdb expects it, in
+// microseconds since the Unix epoch, along with the time left before it as
+// an I/O timeout.
+func readDeadline(ctx context.Context) (deadline, ioTimeout C.uint64_t, ok bool) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return 0, 0, false
+	}
+	// Zero means no timeout to rocksdb, so a deadline that has just passed
+	// is rounded up to the shortest timeout there is.
+	left := max(time.Until(d).Microseconds(), 1)
+	return C.uint64_t(d.UnixMicro()), C.uint64_t(left), true
+}
+
+// contextError reports a read that rocksdb timed out on behalf of ctx as
+// the error of ctx.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || !IsTimedOut(err) {
+		return err
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return context.DeadlineExceeded
+	}
+	return err
+}
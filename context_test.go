@@ -0,0 +1,108 @@
+package rocksgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContextCanceled(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	if err := db.Put(wo, []byte("a"), []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.GetContext(ctx, ro, []byte("a")); err != context.Canceled {
+		t.Errorf("GetContext on a canceled context returned %v", err)
+	}
+	if err := db.PutContext(ctx, wo, []byte("b"), []byte("2")); err != context.Canceled {
+		t.Errorf("PutContext on a canceled context returned %v", err)
+	}
+	wb := NewWriteBatch()
+	defer wb.Close()
+	wb.Put([]byte("c"), []byte("3"))
+	if err := db.WriteContext(ctx, wo, wb); err != context.Canceled {
+		t.Errorf("WriteContext on a canceled context returned %v", err)
+	}
+	if v, _ := db.Get(ro, []byte("b")); v != nil {
+		t.Errorf("PutContext wrote despite the canceled context")
+	}
+	it := db.NewIteratorContext(ctx, ro)
+	it.SeekToFirst()
+	if it.Valid() || it.GetError() != context.Canceled {
+		t.Errorf("an Iterator of a canceled context should be invalid, got %v", it.GetError())
+	}
+	it.Close()
+}
+
+func TestContextIteratorStops(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		if err := db.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := db.NewIteratorContext(ctx, ro)
+	defer it.Close()
+	n := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		n++
+		if n == 2 {
+			cancel()
+		}
+	}
+	if n != 2 {
+		t.Errorf("iterated over %d keys, want the iteration to stop at 2", n)
+	}
+	if err := it.GetError(); err != context.Canceled {
+		t.Errorf("GetError() = %v, want context.Canceled", err)
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	if err := db.Put(wo, []byte("a"), []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if v, err := db.GetContext(ctx, ro, []byte("a")); err != nil || string(v) != "1" {
+		t.Errorf("GetContext with a distant deadline = %q, %v", v, err)
+	}
+	if v, err := db.GetContext(ctx, ro, []byte("missing")); err != nil || v != nil {
+		t.Errorf("GetContext of a missing key = %q, %v", v, err)
+	}
+	it := db.NewIteratorContext(ctx, ro)
+	defer it.Close()
+	it.SeekToFirst()
+	if !it.Valid() || string(it.Key()) != "a" {
+		t.Errorf("an Iterator with a distant deadline should see the key written")
+	}
+
+	expired, cancel2 := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel2()
+	if _, err := db.GetContext(expired, ro, []byte("a")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetContext past the deadline returned %v", err)
+	}
+}
+
+func TestContextError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := contextError(ctx, parseError("Operation timed out: ")); err != context.DeadlineExceeded {
+		t.Errorf("a rocksdb timeout should be reported as context.DeadlineExceeded, got %v", err)
+	}
+	if err := contextError(context.Background(), parseError("Operation timed out: ")); !IsTimedOut(err) {
+		t.Errorf("a timeout without a deadline should be left alone, got %v", err)
+	}
+	if err := contextError(ctx, parseError("Corruption: bad block")); !IsCorruption(err) {
+		t.Errorf("other errors should be left alone, got %v", err)
+	}
+}
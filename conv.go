@@ -22,6 +22,20 @@ func ucharToBool(uc C.uchar) bool {
 	return true
 }
 
+// byteSliceToChar returns a *C.char pointing at the first byte of b, for
+// passing b to rocksdb along with its length. Empty and nil slices give a
+// nil pointer, which rocksdb accepts with a zero length, instead of the
+// panic indexing b[0] would cause.
+//
+// rocksdb copies keys and values it is handed before returning, so the
+// pointer only has to stay valid for the duration of the C call.
+func byteSliceToChar(b []byte) *C.char {
+	if len(b) == 0 {
+		return nil
+	}
+	return (*C.char)(unsafe.Pointer(&b[0]))
+}
+
 // btoi converts a bool value to int
 func btoi(b bool) int {
 	if b {
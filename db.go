@@ -3,6 +3,7 @@ package rocksgo
 /*
 #cgo LDFLAGS: -L../rocksdb -lrocksdb
 #cgo CFLAGS:-I../rocksdb/include
+#cgo CXXFLAGS: -std=c++17 -I../rocksdb/include
 
 #include <stdlib.h>
 #include "rocksdb/c.h"
@@ -33,28 +34,34 @@ void rocksdb_free(void* ptr) {
 import "C"
 
 import (
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
-type DatabaseError string
-
-func (e DatabaseError) Error() string {
-	return string(e)
-}
-
 // DB is a reusable handle to a rocksdb database on disk, created by Open.
 //
 // To avoid memory and file descriptor leaks, call Close when the process no
-// longer needs the handle. Calls to any DB method made after Close will
-// panic.
+// longer needs the handle. Close waits for calls still running in other
+// goroutines, and for open Iterators and Snapshots, before the handle is
+// released. Calls to DB methods made after Close return ErrClosed.
 //
 // The DB instance may be shared between goroutines. The usual data race
 // conditions will occur if the same key is written to from more than one, of
 // course.
 type DB struct {
 	Ldb *C.rocksdb_t
+
+	// refs counts the calls in flight, open Iterators and unreleased
+	// Snapshots. The closingRef bit is set once Close has been called.
+	refs    atomic.Int64
+	drained chan struct{}
 }
 
+// closingRef is set in DB.refs by Close. Whoever drops the count to zero
+// with it set closes the underlying handle.
+const closingRef = int64(1) << 62
+
 // Range is a range of keys in the database. GetApproximateSizes calls with it
 // begin at the key Start and end right before the key Limit.
 type Range struct {
@@ -70,6 +77,7 @@ type Range struct {
 // created it.
 type Snapshot struct {
 	snap *C.rocksdb_snapshot_t
+	leak *leakRecord
 }
 
 // Open opens a database.
@@ -85,12 +93,31 @@ func Open(dbname string, o *Options) (*DB, error) {
 	defer C.rocksdb_free(unsafe.Pointer(ldbname))
 
 	rocksdb := C.rocksdb_open(o.Opt, ldbname, &errStr)
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return nil, DatabaseError(gs)
+	if err := statusError(errStr); err != nil {
+		return nil, err
+	}
+	return &DB{Ldb: rocksdb, drained: make(chan struct{})}, nil
+}
+
+// OpenForReadOnly opens an existing database for reading only. Writes and
+// compactions on the DB returned fail, and several processes may open the
+// same database this way at once, alongside one process having it open with
+// Open. The DB sees the data as of the time it was opened.
+//
+// If errorIfWALFileExists is true, opening fails when the database has a
+// write-ahead log that was not yet flushed, that is, when it was not closed
+// cleanly or is still open elsewhere; otherwise the log is replayed in
+// memory.
+func OpenForReadOnly(dbname string, o *Options, errorIfWALFileExists bool) (*DB, error) {
+	var errStr *C.char
+	ldbname := C.CString(dbname)
+	defer C.rocksdb_free(unsafe.Pointer(ldbname))
+
+	rocksdb := C.rocksdb_open_for_read_only(o.Opt, ldbname, boolToUchar(errorIfWALFileExists), &errStr)
+	if err := statusError(errStr); err != nil {
+		return nil, err
 	}
-	return &DB{rocksdb}, nil
+	return &DB{Ldb: rocksdb, drained: make(chan struct{})}, nil
 }
 
 // DestroyDatabase removes a database entirely, removing everything from the
@@ -101,12 +128,7 @@ func DestroyDatabase(dbname string, o *Options) error {
 	defer C.rocksdb_free(unsafe.Pointer(ldbname))
 
 	C.rocksdb_destroy_db(o.Opt, ldbname, &errStr)
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return DatabaseError(gs)
-	}
-	return nil
+	return statusError(errStr)
 }
 
 // RepairDatabase attempts to repair a database.
@@ -118,12 +140,7 @@ func RepairDatabase(dbname string, o *Options) error {
 	defer C.rocksdb_free(unsafe.Pointer(ldbname))
 
 	C.rocksdb_repair_db(o.Opt, ldbname, &errStr)
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return DatabaseError(gs)
-	}
-	return nil
+	return statusError(errStr)
 }
 
 // Put writes data associated with a key to the database.
@@ -134,29 +151,20 @@ func RepairDatabase(dbname string, o *Options) error {
 // The key and value byte slices may be reused safely. Put takes a copy of
 // them before returning.
 func (db *DB) Put(wo *WriteOptions, key, value []byte) error {
+	if err := db.acquire(); err != nil {
+		return err
+	}
+	defer db.release()
+
 	var errStr *C.char
 	// rocksdb_put, _get, and _delete call memcpy() (by way of Memtable::Add)
 	// when called, so we do not need to worry about these []byte being
 	// reclaimed by GC.
-	var k, v *C.char
-	if len(key) != 0 {
-		k = (*C.char)(unsafe.Pointer(&key[0]))
-	}
-	if len(value) != 0 {
-		v = (*C.char)(unsafe.Pointer(&value[0]))
-	}
-
-	lenk := len(key)
-	lenv := len(value)
-	C.rocksdb_put(
-		db.Ldb, wo.Opt, k, C.size_t(lenk), v, C.size_t(lenv), &errStr)
+	C.rocksdb_put(db.Ldb, wo.Opt,
+		byteSliceToChar(key), C.size_t(len(key)),
+		byteSliceToChar(value), C.size_t(len(value)), &errStr)
 
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return DatabaseError(gs)
-	}
-	return nil
+	return statusError(errStr)
 }
 
 // Get returns the data associated with the key from the database.
@@ -168,20 +176,18 @@ func (db *DB) Put(wo *WriteOptions, key, value []byte) error {
 // The key byte slice may be reused safely. Get takes a copy of
 // them before returning.
 func (db *DB) Get(ro *ReadOptions, key []byte) ([]byte, error) {
-	var errStr *C.char
-	var vallen C.size_t
-	var k *C.char
-	if len(key) != 0 {
-		k = (*C.char)(unsafe.Pointer(&key[0]))
+	if err := db.acquire(); err != nil {
+		return nil, err
 	}
+	defer db.release()
 
-	value := C.rocksdb_get(
-		db.Ldb, ro.Opt, k, C.size_t(len(key)), &vallen, &errStr)
+	var errStr *C.char
+	var vallen C.size_t
+	value := C.rocksdb_get(db.Ldb, ro.Opt,
+		byteSliceToChar(key), C.size_t(len(key)), &vallen, &errStr)
 
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return nil, DatabaseError(gs)
+	if err := statusError(errStr); err != nil {
+		return nil, err
 	}
 
 	if value == nil {
@@ -192,38 +198,103 @@ func (db *DB) Get(ro *ReadOptions, key []byte) ([]byte, error) {
 	return C.GoBytes(unsafe.Pointer(value), C.int(vallen)), nil
 }
 
+// MultiGet returns the data associated with each of the keys, looking them
+// all up at once, which is faster than as many calls to Get. The values are
+// in the order of the keys, nil for those not found, as with Get.
+//
+// If looking up some of the keys fails, the error of the first of them is
+// returned, along with the values of the others.
+//
+// The key byte slices may be reused safely. MultiGet takes a copy of them
+// before returning.
+func (db *DB) MultiGet(ro *ReadOptions, keys [][]byte) ([][]byte, error) {
+	if err := db.acquire(); err != nil {
+		return nil, err
+	}
+	defer db.release()
+
+	n := len(keys)
+	if n == 0 {
+		return [][]byte{}, nil
+	}
+	// cgo does not allow passing C an array of Go pointers, so the keys are
+	// copied into a single C buffer, and the arrays are allocated in C too.
+	total := 0
+	for _, k := range keys {
+		total += len(k)
+	}
+	buf := cArray[byte](max(total, 1))
+	defer C.free(unsafe.Pointer(&buf[0]))
+	keyPtrs, keySizes := cArray[*C.char](n), cArray[C.size_t](n)
+	defer C.free(unsafe.Pointer(&keyPtrs[0]))
+	defer C.free(unsafe.Pointer(&keySizes[0]))
+	valuePtrs, valueSizes := cArray[*C.char](n), cArray[C.size_t](n)
+	defer C.free(unsafe.Pointer(&valuePtrs[0]))
+	defer C.free(unsafe.Pointer(&valueSizes[0]))
+	errPtrs := cArray[*C.char](n)
+	defer C.free(unsafe.Pointer(&errPtrs[0]))
+	off := 0
+	for i, k := range keys {
+		keyPtrs[i] = (*C.char)(unsafe.Add(unsafe.Pointer(&buf[0]), off))
+		keySizes[i] = C.size_t(len(k))
+		off += copy(buf[off:], k)
+	}
+
+	C.rocksdb_multi_get(db.Ldb, ro.Opt, C.size_t(n),
+		&keyPtrs[0], &keySizes[0], &valuePtrs[0], &valueSizes[0], &errPtrs[0])
+
+	values := make([][]byte, n)
+	var firstErr error
+	for i := range values {
+		if err := statusError(errPtrs[i]); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if valuePtrs[i] != nil {
+			values[i] = C.GoBytes(unsafe.Pointer(valuePtrs[i]), C.int(valueSizes[i]))
+			C.rocksdb_free(unsafe.Pointer(valuePtrs[i]))
+		}
+	}
+	return values, firstErr
+}
+
+// cArray allocates an array of n values in C memory, to be freed with
+// C.free.
+func cArray[T any](n int) []T {
+	var zero T
+	p := C.malloc(C.size_t(n) * C.size_t(unsafe.Sizeof(zero)))
+	return unsafe.Slice((*T)(p), n)
+}
+
 // Delete removes the data associated with the key from the database.
 //
 // The key byte slice may be reused safely. Delete takes a copy of
 // them before returning.
 func (db *DB) Delete(wo *WriteOptions, key []byte) error {
-	var errStr *C.char
-	var k *C.char
-	if len(key) != 0 {
-		k = (*C.char)(unsafe.Pointer(&key[0]))
+	if err := db.acquire(); err != nil {
+		return err
 	}
+	defer db.release()
 
-	C.rocksdb_delete(
-		db.Ldb, wo.Opt, k, C.size_t(len(key)), &errStr)
+	var errStr *C.char
+	C.rocksdb_delete(db.Ldb, wo.Opt,
+		byteSliceToChar(key), C.size_t(len(key)), &errStr)
 
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return DatabaseError(gs)
-	}
-	return nil
+	return statusError(errStr)
 }
 
 // Write atomically writes a WriteBatch to disk.
 func (db *DB) Write(wo *WriteOptions, w *WriteBatch) error {
+	if err := db.acquire(); err != nil {
+		return err
+	}
+	defer db.release()
+
 	var errStr *C.char
 	C.rocksdb_write(db.Ldb, wo.Opt, w.wbatch, &errStr)
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return DatabaseError(gs)
-	}
-	return nil
+	return statusError(errStr)
 }
 
 // NewIterator returns an Iterator over the the database that uses the
@@ -236,9 +307,17 @@ func (db *DB) Write(wo *WriteOptions, w *WriteBatch) error {
 // before passing it here.
 //
 // Similiarly, ReadOptions.SetSnapshot is also useful.
+//
+// The DB will not finish closing until the Iterator is closed. If the DB has
+// already been closed, the Iterator returned is invalid and its GetError
+// returns ErrClosed.
 func (db *DB) NewIterator(ro *ReadOptions) *Iterator {
-	it := C.rocksdb_create_iterator(db.Ldb, ro.Opt)
-	return &Iterator{Iter: it}
+	if err := db.acquire(); err != nil {
+		return &Iterator{err: err}
+	}
+	it := &Iterator{Iter: C.rocksdb_create_iterator(db.Ldb, ro.Opt), db: db}
+	it.leak = trackResource(it, "Iterator", db)
+	return it
 }
 
 // GetApproximateSizes returns the approximate number of bytes of file system
@@ -246,15 +325,28 @@ func (db *DB) NewIterator(ro *ReadOptions) *Iterator {
 //
 // The keys counted will begin at Range.Start and end on the key before
 // Range.Limit.
+//
+// If the DB has been closed, nil is returned.
 func (db *DB) GetApproximateSizes(ranges []Range) []uint64 {
+	if db.acquire() != nil {
+		return nil
+	}
+	defer db.release()
+
+	if len(ranges) == 0 {
+		return []uint64{}
+	}
+	// The keys are copied into C memory because cgo does not allow passing
+	// C an array of Go pointers. C.CBytes, unlike C.CString, keeps any NUL
+	// bytes in the keys.
 	starts := make([]*C.char, len(ranges))
 	limits := make([]*C.char, len(ranges))
 	startLens := make([]C.size_t, len(ranges))
 	limitLens := make([]C.size_t, len(ranges))
 	for i, r := range ranges {
-		starts[i] = C.CString(string(r.Start))
+		starts[i] = (*C.char)(C.CBytes(r.Start))
 		startLens[i] = C.size_t(len(r.Start))
-		limits[i] = C.CString(string(r.Limit))
+		limits[i] = (*C.char)(C.CBytes(r.Limit))
 		limitLens[i] = C.size_t(len(r.Limit))
 	}
 	sizes := make([]uint64, len(ranges))
@@ -278,13 +370,34 @@ func (db *DB) GetApproximateSizes(ranges []Range) []uint64 {
 //
 // Examples of properties include "rocksdb.stats", "rocksdb.sstables",
 // and "rocksdb.num-files-at-level0".
+//
+// An empty string is returned both for unknown properties and for properties
+// whose value is empty. Use GetIntProperty and GetMapProperty to tell the two
+// apart.
 func (db *DB) PropertyValue(propName string) string {
-	cname := C.CString(propName)
-	value := C.GoString(C.rocksdb_property_value(db.Ldb, cname))
-	C.rocksdb_free(unsafe.Pointer(cname))
+	value, _ := db.getProperty(propName)
 	return value
 }
 
+func (db *DB) getProperty(propName string) (string, bool) {
+	if db.acquire() != nil {
+		return "", false
+	}
+	defer db.release()
+
+	cname := C.CString(propName)
+	defer C.rocksdb_free(unsafe.Pointer(cname))
+
+	cvalue := C.rocksdb_property_value(db.Ldb, cname)
+	if cvalue == nil {
+		return "", false
+	}
+	// Unlike the other values handed back by rocksdb, property values are
+	// malloc'd copies and must be freed here.
+	defer C.rocksdb_free(unsafe.Pointer(cvalue))
+	return C.GoString(cvalue), true
+}
+
 // NewSnapshot creates a new snapshot of the database.
 //
 // The snapshot, when used in a ReadOptions, provides a consistent view of
@@ -295,34 +408,112 @@ func (db *DB) PropertyValue(propName string) string {
 // created it.
 //
 // See the rocksdb documentation for details.
+//
+// The DB will not finish closing until the snapshot is released. If the DB
+// has already been closed, the snapshot returned is empty and reads using it
+// will see ErrClosed.
 func (db *DB) NewSnapshot() *Snapshot {
-	return &Snapshot{C.rocksdb_create_snapshot(db.Ldb)}
+	if db.acquire() != nil {
+		return &Snapshot{}
+	}
+	snap := &Snapshot{snap: C.rocksdb_create_snapshot(db.Ldb)}
+	snap.leak = trackResource(snap, "Snapshot", db)
+	return snap
 }
 
 // ReleaseSnapshot removes the snapshot from the database's list of snapshots,
 // and deallocates it.
+//
+// Releasing a snapshot more than once is a no-op.
 func (db *DB) ReleaseSnapshot(snap *Snapshot) {
+	if snap.snap == nil {
+		return
+	}
 	C.rocksdb_release_snapshot(db.Ldb, snap.snap)
+	snap.snap = nil
+	snap.leak.untrack()
+	db.release()
 }
 
 // CompactRange runs a manual compaction on the Range of keys given. This is
 // not likely to be needed for typical usage.
-func (db *DB) CompactRange(r Range) {
-	var start, limit *C.char
-	if len(r.Start) != 0 {
-		start = (*C.char)(unsafe.Pointer(&r.Start[0]))
-	}
-	if len(r.Limit) != 0 {
-		limit = (*C.char)(unsafe.Pointer(&r.Limit[0]))
+func (db *DB) CompactRange(r Range) error {
+	if err := db.acquire(); err != nil {
+		return err
 	}
-	C.rocksdb_compact_range(
-		db.Ldb, start, C.size_t(len(r.Start)), limit, C.size_t(len(r.Limit)))
+	defer db.release()
+
+	C.rocksdb_compact_range(db.Ldb,
+		byteSliceToChar(r.Start), C.size_t(len(r.Start)),
+		byteSliceToChar(r.Limit), C.size_t(len(r.Limit)))
+	return nil
 }
 
 // Close closes the database, rendering it unusable for I/O, by deallocating
 // the underlying handle.
 //
-// Any attempts to use the DB after Close is called will panic.
-func (db *DB) Close() {
+// Close blocks until the calls running in other goroutines have returned and
+// every Iterator and Snapshot of the DB has been closed or released. Calls
+// made after Close has started return ErrClosed, as does a second Close.
+func (db *DB) Close() error {
+	return db.CloseTimeout(0)
+}
+
+// CloseTimeout is like Close, but gives up waiting after timeout and returns
+// ErrCloseTimeout. The DB still closes in the background as soon as the
+// outstanding calls, Iterators and Snapshots are done with it.
+//
+// A timeout of zero or less waits forever.
+func (db *DB) CloseTimeout(timeout time.Duration) error {
+	old := db.refs.Or(closingRef)
+	if old&closingRef != 0 {
+		return ErrClosed
+	}
+	if old == 0 {
+		db.closeHandle()
+		return nil
+	}
+	if leakDetection.Load() {
+		db.reportOpenResources()
+	}
+	if timeout <= 0 {
+		<-db.drained
+		return nil
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-db.drained:
+		return nil
+	case <-t.C:
+		return ErrCloseTimeout
+	}
+}
+
+// acquire registers a user of the underlying handle, which must be matched
+// by a call to release. It fails with ErrClosed once Close has been called.
+func (db *DB) acquire() error {
+	for {
+		n := db.refs.Load()
+		if n&closingRef != 0 {
+			return ErrClosed
+		}
+		if db.refs.CompareAndSwap(n, n+1) {
+			return nil
+		}
+	}
+}
+
+// release drops a reference taken by acquire, closing the handle if it was
+// the last one and Close has been called.
+func (db *DB) release() {
+	if db.refs.Add(-1) == closingRef {
+		db.closeHandle()
+	}
+}
+
+func (db *DB) closeHandle() {
 	C.rocksdb_close(db.Ldb)
+	db.Ldb = nil
+	close(db.drained)
 }
@@ -37,6 +37,18 @@ ReadOptions you use when creating the Iterator.
 		...
 	}
 
+The same scan can be written with range over DB.Range, which also closes the
+Iterator and checks its error. DB.All, DB.Prefix and DB.Backward scan the whole
+database, the keys with a prefix, and a range in reverse.
+
+	var err error
+	for k, v := range db.Range(ro, mykey, nil, &err) {
+		munge(k, v)
+	}
+	if err != nil {
+		...
+	}
+
 Batched, atomic writes can be performed with a WriteBatch and
 DB.Write.
 
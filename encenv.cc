@@ -0,0 +1,113 @@
+// An Env encrypting every file it writes, with the keys and the cipher
+// supplied from Go.
+
+#include <memory>
+#include <string>
+
+#include "rocksdb/env_encryption.h"
+#include "rocksgo_internal.h"
+
+using rocksdb::BlockAccessCipherStream;
+using rocksdb::EncryptionProvider;
+using rocksdb::EnvOptions;
+using rocksdb::Slice;
+using rocksdb::Status;
+
+namespace {
+
+// GoError converts an error string malloc'd by Go into a Status, freeing
+// it.
+Status GoError(char* err) {
+  if (err == NULL) {
+    return Status::OK();
+  }
+  Status s = Status::IOError(err);
+  free(err);
+  return s;
+}
+
+// GoCipherStream encrypts and decrypts the data of one file by calling
+// into Go, which holds the key and the IV of the file under the id.
+class GoCipherStream : public BlockAccessCipherStream {
+ public:
+  explicit GoCipherStream(uintptr_t id) : id_(id) {}
+  ~GoCipherStream() override { rocksgoCipherStreamRelease(id_); }
+
+  size_t BlockSize() override { return ROCKSGO_CIPHER_BLOCK_SIZE; }
+
+  Status Encrypt(uint64_t offset, char* data, size_t size) override {
+    return GoError(rocksgoCipherStreamXOR(id_, offset, data, size));
+  }
+
+  // CTR mode encryption and decryption are the same operation.
+  Status Decrypt(uint64_t offset, char* data, size_t size) override {
+    return GoError(rocksgoCipherStreamXOR(id_, offset, data, size));
+  }
+
+ protected:
+  // Encrypt and Decrypt above handle whole buffers, so these are never
+  // called.
+  void AllocateScratch(std::string&) override {}
+  Status EncryptBlock(uint64_t, char*, char*) override {
+    return Status::NotSupported();
+  }
+  Status DecryptBlock(uint64_t, char*, char*) override {
+    return Status::NotSupported();
+  }
+
+ private:
+  uintptr_t id_;
+};
+
+// GoEncryptionProvider writes and reads the file prefixes holding the key
+// ID and IV of each file by calling into Go, which holds the KeyProvider
+// under the id.
+class GoEncryptionProvider : public EncryptionProvider {
+ public:
+  explicit GoEncryptionProvider(uintptr_t id) : id_(id) {}
+  ~GoEncryptionProvider() override { rocksgoEncryptionRelease(id_); }
+
+  const char* Name() const override { return "rocksgo.GoEncryptionProvider"; }
+
+  size_t GetPrefixLength() const override { return ROCKSGO_ENCRYPTION_PREFIX; }
+
+  Status CreateNewPrefix(const std::string& /*fname*/, char* prefix,
+                         size_t len) const override {
+    return GoError(rocksgoEncryptionNewPrefix(id_, prefix, len));
+  }
+
+  Status AddCipher(const std::string& /*descriptor*/, const char* /*cipher*/,
+                   size_t /*len*/, bool /*for_write*/) override {
+    return Status::NotSupported("keys come from the Go KeyProvider");
+  }
+
+  Status CreateCipherStream(
+      const std::string& /*fname*/, const EnvOptions& /*options*/,
+      Slice& prefix,
+      std::unique_ptr<BlockAccessCipherStream>* result) override {
+    uintptr_t stream = 0;
+    Status s = GoError(rocksgoEncryptionOpenStream(
+        id_, const_cast<char*>(prefix.data()), prefix.size(), &stream));
+    if (s.ok()) {
+      result->reset(new GoCipherStream(stream));
+    }
+    return s;
+  }
+
+ private:
+  uintptr_t id_;
+};
+
+}  // namespace
+
+extern "C" {
+
+rocksdb_env_t* rocksgo_encrypted_env_create(uintptr_t id) {
+  rocksdb_env_t* env = new rocksdb_env_t;
+  env->rep = rocksdb::NewEncryptedEnv(
+      rocksdb::Env::Default(), std::make_shared<GoEncryptionProvider>(id));
+  env->is_default = false;
+  return env;
+}
+
+}  // extern "C"
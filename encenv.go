@@ -0,0 +1,216 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// KeyProvider supplies the AES keys of an encrypted Env. Keys are 16, 24
+// or 32 bytes long, selecting AES-128, AES-192 or AES-256, and are known by
+// an ID of at most 255 bytes, which is stored in the clear at the start of
+// every file.
+//
+// A KeyProvider is called from rocksdb's threads, and must be safe for
+// concurrent use.
+type KeyProvider interface {
+	// CurrentKey returns the key new files are encrypted with, and its ID.
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given ID, to read a file written with
+	// it. It is called every time a file is opened.
+	Key(id string) ([]byte, error)
+}
+
+// NewEncryptedEnv creates an environment encrypting every file it writes,
+// for use in an Options. The data of each file is encrypted with AES in CTR
+// mode, under the current key of kp and an IV drawn at random for the file.
+// The ID of the key and the IV are kept in a plaintext header of 4096 bytes
+// at the start of the file.
+//
+// Keys are rotated by having kp return a new current key: the files
+// written from then on use it, while older files remain readable as long as
+// kp still returns their key from Key. Files only move to the new key as
+// they are rewritten by compactions; a full CompactRange rewrites every SST
+// file.
+//
+// The files the database writes to its directory are encrypted, including
+// the SST files, the WAL and the MANIFEST. An existing unencrypted database
+// cannot be opened with an encrypted Env, nor the reverse.
+//
+// To prevent memory leaks, the Env returned should be deallocated with
+// Close, after closing the databases using it.
+func NewEncryptedEnv(kp KeyProvider) *Env {
+	id := keyProviders.register(kp)
+	env := &Env{Env: C.rocksgo_encrypted_env_create(C.uintptr_t(id))}
+	env.leak = trackResource(env, "Env", nil)
+	return env
+}
+
+var keyProviders handleRegistry[KeyProvider]
+
+// cipherStreams holds the cipher of every encrypted file open.
+var cipherStreams cipherStreamTable
+
+// cipherStreamTable is a handleRegistry for cipherStreams, which are looked
+// up on every block rocksdb reads or writes in an encrypted file: its
+// lookups take no lock.
+type cipherStreamTable struct {
+	next atomic.Uintptr
+	m    sync.Map // of uintptr to *cipherStream
+}
+
+func (t *cipherStreamTable) register(s *cipherStream) uintptr {
+	id := t.next.Add(1)
+	t.m.Store(id, s)
+	return id
+}
+
+func (t *cipherStreamTable) unregister(id uintptr) {
+	t.m.Delete(id)
+}
+
+func (t *cipherStreamTable) get(id uintptr) (*cipherStream, bool) {
+	v, ok := t.m.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return v.(*cipherStream), true
+}
+
+// The header at the start of every encrypted file is made of encMagic, the
+// version of the format, the length of the key ID, the key ID, and the IV,
+// padded with zeros to C.ROCKSGO_ENCRYPTION_PREFIX bytes.
+const (
+	encMagic   = "rocksgoE"
+	encVersion = 1
+)
+
+// newEncryptionPrefix fills prefix with the header of a new file.
+func newEncryptionPrefix(kp KeyProvider, prefix []byte) error {
+	id, key, err := kp.CurrentKey()
+	if err != nil {
+		return err
+	}
+	if len(id) > 255 {
+		return fmt.Errorf("rocksgo: key ID %q longer than 255 bytes", id)
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("rocksgo: key %q: %w", id, err)
+	}
+	clear(prefix)
+	n := copy(prefix, encMagic)
+	prefix[n] = encVersion
+	prefix[n+1] = byte(len(id))
+	n += 2
+	n += copy(prefix[n:], id)
+	_, err = rand.Read(prefix[n : n+aes.BlockSize])
+	return err
+}
+
+// openCipherStream returns the cipher of a file from its header.
+func openCipherStream(kp KeyProvider, prefix []byte) (*cipherStream, error) {
+	if len(prefix) < len(encMagic)+2 || string(prefix[:len(encMagic)]) != encMagic {
+		return nil, errors.New("rocksgo: file is not encrypted by rocksgo")
+	}
+	n := len(encMagic)
+	if prefix[n] != encVersion {
+		return nil, fmt.Errorf("rocksgo: unknown encryption format version %d", prefix[n])
+	}
+	idLen := int(prefix[n+1])
+	n += 2
+	if len(prefix) < n+idLen+aes.BlockSize {
+		return nil, errors.New("rocksgo: truncated encryption header")
+	}
+	id := string(prefix[n : n+idLen])
+	key, err := kp.Key(id)
+	if err != nil {
+		return nil, fmt.Errorf("rocksgo: key %q: %w", id, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("rocksgo: key %q: %w", id, err)
+	}
+	s := &cipherStream{block: block}
+	copy(s.iv[:], prefix[n+idLen:])
+	return s, nil
+}
+
+// cipherStream encrypts and decrypts the data of one file. CTR mode lets
+// it start anywhere in the file. It is safe for concurrent use.
+type cipherStream struct {
+	block cipher.Block
+	iv    [aes.BlockSize]byte
+
+	// mu guards the CTR stream of the last call, kept to continue where it
+	// stopped: rocksdb writes files, and mostly reads them, sequentially,
+	// which saves building a stream for every block.
+	mu     sync.Mutex
+	stream cipher.Stream
+	next   uint64 // the offset in the file stream is at
+}
+
+// xor encrypts or decrypts data, found at offset in the file, in place.
+func (s *cipherStream) xor(offset uint64, data []byte) {
+	if !s.mu.TryLock() {
+		// Another block of the file is being read, as in parallel reads
+		// of an SST file; use a stream of our own rather than wait.
+		s.newStream(offset).XORKeyStream(data, data)
+		return
+	}
+	defer s.mu.Unlock()
+	if s.stream == nil || s.next != offset {
+		s.stream = s.newStream(offset)
+	}
+	s.stream.XORKeyStream(data, data)
+	s.next = offset + uint64(len(data))
+}
+
+// newStream returns a CTR stream starting at offset in the file.
+func (s *cipherStream) newStream(offset uint64) cipher.Stream {
+	// The counter of the block holding offset is the IV plus the index of
+	// the block, as a 128 bit big-endian integer.
+	var ctr [aes.BlockSize]byte
+	copy(ctr[:], s.iv[:])
+	carry := offset / aes.BlockSize
+	for i := aes.BlockSize - 1; i >= 0 && carry != 0; i-- {
+		sum := uint64(ctr[i]) + carry&0xff
+		ctr[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(s.block, ctr[:])
+	if skip := offset % aes.BlockSize; skip != 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	return stream
+}
+
+// cError returns err as a C string for rocksdb to free, or nil.
+func cError(err error) *C.char {
+	if err == nil {
+		return nil
+	}
+	return C.CString(err.Error())
+}
+
+func errUnknownHandle(kind string, id C.uintptr_t) error {
+	return fmt.Errorf("rocksgo: unknown %s %d", kind, id)
+}
+
+// unsafeBytes views C memory as a byte slice.
+func unsafeBytes(p *C.char, n C.size_t) []byte {
+	if n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), n)
+}
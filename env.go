@@ -9,10 +9,21 @@ import "C"
 // Typically, NewDefaultEnv is all you need. Advanced users may create their
 // own Env with a *C.rocksdb_env_t of their own creation.
 //
+// The background flushes and compactions of every database using an Env
+// run on its two thread pools: the LOW priority pool, for compactions, and
+// the HIGH priority pool, for flushes. Sharing one Env across several
+// databases gives them a common budget of background threads, sized with
+// SetBackgroundThreads and SetHighPriorityBackgroundThreads. The
+// SetMaxBackgroundCompactions and SetMaxBackgroundFlushes options of each
+// database bound how many of those threads it may use at once; opening a
+// database grows the pools to at least those numbers, but never shrinks
+// them.
+//
 // To prevent memory leaks, an Env must have Close called on it when it is
 // no longer needed by the program.
 type Env struct {
-	Env *C.rocksdb_env_t
+	Env  *C.rocksdb_env_t
+	leak *leakRecord
 }
 
 // NewDefaultEnv creates a default environment for use in an Options.
@@ -20,10 +31,82 @@ type Env struct {
 // To prevent memory leaks, the Env returned should be deallocated with
 // Close.
 func NewDefaultEnv() *Env {
-	return &Env{C.rocksdb_create_default_env()}
+	env := &Env{Env: C.rocksdb_create_default_env()}
+	env.leak = trackResource(env, "Env", nil)
+	return env
+}
+
+// NewMemEnv creates an environment keeping every file of the databases
+// using it in memory, for use in an Options. Nothing is written to disk, and
+// the databases are lost once the Env is closed, which makes it well suited
+// to tests.
+//
+// Files are only visible to the databases using the same Env, whatever
+// their path. The Env must outlive every database opened with it, and
+// should be deallocated with Close.
+func NewMemEnv() *Env {
+	env := &Env{Env: C.rocksdb_create_mem_env()}
+	env.leak = trackResource(env, "Env", nil)
+	return env
+}
+
+// SetBackgroundThreads sets the number of threads of the LOW priority pool,
+// running compactions, and of flushes too when no database using the Env
+// sets SetMaxBackgroundFlushes.
+func (env *Env) SetBackgroundThreads(n int) {
+	C.rocksdb_env_set_background_threads(env.Env, C.int(n))
+}
+
+// SetHighPriorityBackgroundThreads sets the number of threads of the HIGH
+// priority pool, running the flushes of the databases using the Env that
+// set SetMaxBackgroundFlushes.
+func (env *Env) SetHighPriorityBackgroundThreads(n int) {
+	C.rocksdb_env_set_high_priority_background_threads(env.Env, C.int(n))
+}
+
+// BackgroundThreads returns the number of threads of the LOW priority pool.
+// Opening a database raises it to the number of compactions the database
+// may run at once, if lower.
+func (env *Env) BackgroundThreads() int {
+	return int(C.rocksdb_env_get_background_threads(env.Env))
+}
+
+// HighPriorityBackgroundThreads returns the number of threads of the HIGH
+// priority pool. Opening a database raises it to the number of flushes the
+// database may run at once, if lower.
+func (env *Env) HighPriorityBackgroundThreads() int {
+	return int(C.rocksdb_env_get_high_priority_background_threads(env.Env))
+}
+
+// LowerThreadPoolIOPriority lowers the I/O priority of the threads of the
+// LOW priority pool, so that compactions yield the disk to foreground
+// reads and writes. It only has an effect on Linux.
+func (env *Env) LowerThreadPoolIOPriority() {
+	C.rocksdb_env_lower_thread_pool_io_priority(env.Env)
+}
+
+// LowerThreadPoolCPUPriority lowers the CPU priority of the threads of the
+// LOW priority pool, so that compactions yield the CPU to foreground work.
+// It only has an effect on POSIX systems.
+func (env *Env) LowerThreadPoolCPUPriority() {
+	C.rocksdb_env_lower_thread_pool_cpu_priority(env.Env)
+}
+
+// JoinAllThreads waits for the threads of both pools to finish their jobs
+// and exit. It must only be called once every database using the Env is
+// closed; the pools are restarted by the next job submitted.
+func (env *Env) JoinAllThreads() {
+	C.rocksdb_env_join_all_threads(env.Env)
 }
 
 // Close deallocates the Env, freeing the underlying struct.
+//
+// Closing an Env more than once is a no-op.
 func (env *Env) Close() {
+	if env.Env == nil {
+		return
+	}
 	C.rocksdb_env_destroy(env.Env)
+	env.Env = nil
+	env.leak.untrack()
 }
@@ -0,0 +1,66 @@
+package rocksgo
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEnvSharedThreadPools(t *testing.T) {
+	env := NewDefaultEnv()
+	defer env.Close()
+	env.SetBackgroundThreads(2)
+	env.SetHighPriorityBackgroundThreads(1)
+	if low, high := env.BackgroundThreads(), env.HighPriorityBackgroundThreads(); low != 2 || high != 1 {
+		t.Fatalf("the pools have %d and %d threads, want 2 and 1", low, high)
+	}
+	// rocksdb offers no way to read the priorities back: this only checks
+	// that lowering them does not get in the way of the databases below.
+	env.LowerThreadPoolIOPriority()
+	env.LowerThreadPoolCPUPriority()
+
+	wo := NewWriteOptions()
+	defer wo.Close()
+	ro := NewReadOptions()
+	defer ro.Close()
+
+	// The first database fits in the pools, which stay as set; the second
+	// may run more compactions at once, and grows the pool of the Env both
+	// use.
+	var dbs []*DB
+	for i, compactions := range []int{2, 4} {
+		options := NewOptions()
+		defer options.Close()
+		options.SetCreateIfMissing(true)
+		options.SetEnv(env)
+		options.SetMaxBackgroundCompactions(compactions)
+		options.SetMaxBackgroundFlushes(1)
+		dbname := tempDir(t)
+		defer deleteDBDirectory(t, dbname)
+		db, err := Open(dbname, options)
+		if err != nil {
+			t.Fatalf("Database could not be opened: %v", err)
+		}
+		dbs = append(dbs, db)
+		if low, high := env.BackgroundThreads(), env.HighPriorityBackgroundThreads(); low != compactions || high != 1 {
+			t.Errorf("after opening db %d, the pools have %d and %d threads, want %d and 1", i, low, high, compactions)
+		}
+	}
+	for i, db := range dbs {
+		for j := 0; j < 100; j++ {
+			key := []byte(fmt.Sprintf("key%03d", j))
+			if err := db.Put(wo, key, []byte{byte(i)}); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := db.CompactRange(Range{}); err != nil {
+			t.Fatalf("CompactRange failed: %v", err)
+		}
+	}
+	for i, db := range dbs {
+		if v, err := db.Get(ro, []byte("key042")); err != nil || len(v) != 1 || v[0] != byte(i) {
+			t.Errorf("db %d: Get = %v, %v", i, v, err)
+		}
+		db.Close()
+	}
+	env.JoinAllThreads()
+}
@@ -0,0 +1,281 @@
+package rocksgo
+
+// #include <stdlib.h>
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// Code is the kind of failure reported by rocksdb. The values match
+// rocksdb's Status::Code.
+type Code int
+
+const (
+	CodeOK                  = Code(0)
+	CodeNotFound            = Code(1)
+	CodeCorruption          = Code(2)
+	CodeNotSupported        = Code(3)
+	CodeInvalidArgument     = Code(4)
+	CodeIOError             = Code(5)
+	CodeMergeInProgress     = Code(6)
+	CodeIncomplete          = Code(7)
+	CodeShutdownInProgress  = Code(8)
+	CodeTimedOut            = Code(9)
+	CodeAborted             = Code(10)
+	CodeBusy                = Code(11)
+	CodeExpired             = Code(12)
+	CodeTryAgain            = Code(13)
+	CodeCompactionTooLarge  = Code(14)
+	CodeColumnFamilyDropped = Code(15)
+)
+
+// SubCode refines a Code, e.g. an IOError caused by a full disk. The values
+// match rocksdb's Status::SubCode.
+type SubCode int
+
+const (
+	SubCodeNone                   = SubCode(0)
+	SubCodeMutexTimeout           = SubCode(1)
+	SubCodeLockTimeout            = SubCode(2)
+	SubCodeLockLimit              = SubCode(3)
+	SubCodeNoSpace                = SubCode(4)
+	SubCodeDeadlock               = SubCode(5)
+	SubCodeStaleFile              = SubCode(6)
+	SubCodeMemoryLimit            = SubCode(7)
+	SubCodeSpaceLimit             = SubCode(8)
+	SubCodePathNotFound           = SubCode(9)
+	SubCodeMergeOperandsTooLarge  = SubCode(10)
+	SubCodeManualCompactionPaused = SubCode(11)
+)
+
+// codePrefixes are the prefixes rocksdb's Status::ToString puts in front of
+// the message for each Code.
+var codePrefixes = []struct {
+	code   Code
+	prefix string
+}{
+	{CodeNotFound, "NotFound: "},
+	{CodeCorruption, "Corruption: "},
+	{CodeNotSupported, "Not implemented: "},
+	{CodeInvalidArgument, "Invalid argument: "},
+	{CodeIOError, "IO error: "},
+	{CodeMergeInProgress, "Merge in progress: "},
+	{CodeIncomplete, "Result incomplete: "},
+	{CodeShutdownInProgress, "Shutdown in progress: "},
+	{CodeTimedOut, "Operation timed out: "},
+	{CodeAborted, "Operation aborted: "},
+	{CodeBusy, "Resource busy: "},
+	{CodeExpired, "Operation expired: "},
+	{CodeTryAgain, "Operation failed. Try again.: "},
+	{CodeCompactionTooLarge, "Compaction too large: "},
+	{CodeColumnFamilyDropped, "Column family dropped: "},
+}
+
+// subCodeMessages are the texts rocksdb's Status::ToString puts after the
+// Code prefix for each SubCode.
+var subCodeMessages = []struct {
+	subCode SubCode
+	msg     string
+}{
+	{SubCodeMutexTimeout, "Timeout Acquiring Mutex"},
+	{SubCodeLockTimeout, "Timeout waiting to lock key"},
+	{SubCodeLockLimit, "Failed to acquire lock due to max_num_locks limit"},
+	{SubCodeNoSpace, "No space left on device"},
+	{SubCodeDeadlock, "Deadlock"},
+	{SubCodeStaleFile, "Stale file handle"},
+	{SubCodeMemoryLimit, "Memory limit reached"},
+	{SubCodeSpaceLimit, "Space limit reached"},
+	{SubCodePathNotFound, "No such file or directory"},
+	{SubCodeMergeOperandsTooLarge, "Insufficient capacity for merge operands"},
+	{SubCodeManualCompactionPaused, "Manual compaction paused"},
+}
+
+// String returns the name of the code, e.g. "NotFound".
+func (c Code) String() string {
+	switch c {
+	case CodeOK:
+		return "OK"
+	case CodeNotFound:
+		return "NotFound"
+	case CodeCorruption:
+		return "Corruption"
+	case CodeNotSupported:
+		return "NotSupported"
+	case CodeInvalidArgument:
+		return "InvalidArgument"
+	case CodeIOError:
+		return "IOError"
+	case CodeMergeInProgress:
+		return "MergeInProgress"
+	case CodeIncomplete:
+		return "Incomplete"
+	case CodeShutdownInProgress:
+		return "ShutdownInProgress"
+	case CodeTimedOut:
+		return "TimedOut"
+	case CodeAborted:
+		return "Aborted"
+	case CodeBusy:
+		return "Busy"
+	case CodeExpired:
+		return "Expired"
+	case CodeTryAgain:
+		return "TryAgain"
+	case CodeCompactionTooLarge:
+		return "CompactionTooLarge"
+	case CodeColumnFamilyDropped:
+		return "ColumnFamilyDropped"
+	}
+	return "Unknown"
+}
+
+// Error is an error reported by rocksdb.
+//
+// Use errors.Is with the Err* values, or the Is* helpers, to check for a
+// particular Code:
+//
+//	if rocksgo.IsCorruption(err) {
+//		...
+//	}
+//	if errors.Is(err, rocksgo.ErrNoSpace) {
+//		...
+//	}
+type Error struct {
+	Code    Code
+	SubCode SubCode
+	// Message is the detail rocksdb gave, without the Code and SubCode
+	// texts in front of it.
+	Message string
+
+	text string
+}
+
+// Error returns the error text exactly as rocksdb reported it.
+func (e *Error) Error() string {
+	if e.text == "" {
+		return e.Code.String()
+	}
+	return e.text
+}
+
+// Is reports whether target is one of the Err* values matching e. A target
+// with SubCodeNone matches errors of the same Code with any SubCode.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.text != "" {
+		return false
+	}
+	return t.Code == e.Code && (t.SubCode == SubCodeNone || t.SubCode == e.SubCode)
+}
+
+// Values for use with errors.Is. They match any *Error with the same Code
+// (and SubCode, if set).
+var (
+	ErrNotFound           = &Error{Code: CodeNotFound}
+	ErrCorruption         = &Error{Code: CodeCorruption}
+	ErrNotSupported       = &Error{Code: CodeNotSupported}
+	ErrInvalidArgument    = &Error{Code: CodeInvalidArgument}
+	ErrIOError            = &Error{Code: CodeIOError}
+	ErrMergeInProgress    = &Error{Code: CodeMergeInProgress}
+	ErrIncomplete         = &Error{Code: CodeIncomplete}
+	ErrShutdownInProgress = &Error{Code: CodeShutdownInProgress}
+	ErrTimedOut           = &Error{Code: CodeTimedOut}
+	ErrAborted            = &Error{Code: CodeAborted}
+	ErrBusy               = &Error{Code: CodeBusy}
+	ErrExpired            = &Error{Code: CodeExpired}
+	ErrTryAgain           = &Error{Code: CodeTryAgain}
+
+	ErrNoSpace      = &Error{Code: CodeIOError, SubCode: SubCodeNoSpace}
+	ErrPathNotFound = &Error{Code: CodeIOError, SubCode: SubCodePathNotFound}
+)
+
+var (
+	// ErrClosed is returned by DB methods called after DB.Close.
+	ErrClosed = errors.New("rocksgo: database is closed")
+
+	// ErrCloseTimeout is returned by DB.CloseTimeout when the DB is still in
+	// use once the timeout expires.
+	ErrCloseTimeout = errors.New("rocksgo: timed out waiting for database users to finish")
+
+	// ErrWriterClosed is returned by BatchingWriter methods called after
+	// BatchingWriter.Close.
+	ErrWriterClosed = errors.New("rocksgo: batching writer is closed")
+
+	// ErrSstFileNotOpen is returned by SstFileReader methods called before
+	// a file was successfully opened.
+	ErrSstFileNotOpen = errors.New("rocksgo: SST file reader has no file open")
+)
+
+// IsNotFound reports whether err is a rocksdb NotFound error.
+func IsNotFound(err error) bool { return hasCode(err, CodeNotFound) }
+
+// IsCorruption reports whether err is a rocksdb Corruption error.
+func IsCorruption(err error) bool { return hasCode(err, CodeCorruption) }
+
+// IsNotSupported reports whether err is a rocksdb NotSupported error.
+func IsNotSupported(err error) bool { return hasCode(err, CodeNotSupported) }
+
+// IsInvalidArgument reports whether err is a rocksdb InvalidArgument error.
+func IsInvalidArgument(err error) bool { return hasCode(err, CodeInvalidArgument) }
+
+// IsIOError reports whether err is a rocksdb IOError.
+func IsIOError(err error) bool { return hasCode(err, CodeIOError) }
+
+// IsIncomplete reports whether err is a rocksdb Incomplete error.
+func IsIncomplete(err error) bool { return hasCode(err, CodeIncomplete) }
+
+// IsShutdownInProgress reports whether err is a rocksdb ShutdownInProgress
+// error.
+func IsShutdownInProgress(err error) bool { return hasCode(err, CodeShutdownInProgress) }
+
+// IsTimedOut reports whether err is a rocksdb TimedOut error.
+func IsTimedOut(err error) bool { return hasCode(err, CodeTimedOut) }
+
+// IsBusy reports whether err is a rocksdb Busy error.
+func IsBusy(err error) bool { return hasCode(err, CodeBusy) }
+
+// IsTryAgain reports whether err is a rocksdb TryAgain error.
+func IsTryAgain(err error) bool { return hasCode(err, CodeTryAgain) }
+
+func hasCode(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// parseError builds an *Error from the text of a rocksdb Status.
+func parseError(text string) *Error {
+	e := &Error{Code: CodeIOError, Message: text, text: text}
+	// Statuses without a recognizable prefix are reported as IOErrors, the
+	// same as rocksdb does for unexpected failures of the environment.
+	for _, p := range codePrefixes {
+		if strings.HasPrefix(text, p.prefix) || text == strings.TrimSuffix(p.prefix, ": ") {
+			e.Code = p.code
+			e.Message = strings.TrimPrefix(text, p.prefix)
+			break
+		}
+	}
+	for _, s := range subCodeMessages {
+		if strings.HasPrefix(e.Message, s.msg) {
+			e.SubCode = s.subCode
+			e.Message = strings.TrimPrefix(strings.TrimPrefix(e.Message, s.msg), ": ")
+			break
+		}
+	}
+	return e
+}
+
+// statusError converts an error string set by a rocksdb C call into an
+// *Error, freeing the string. It returns nil if errStr is nil.
+func statusError(errStr *C.char) error {
+	if errStr == nil {
+		return nil
+	}
+	gs := C.GoString(errStr)
+	C.rocksdb_free(unsafe.Pointer(errStr))
+	return parseError(gs)
+}
@@ -0,0 +1,306 @@
+package rocksgo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExportFormat is how Export writes key-value pairs, and Import reads them.
+type ExportFormat int
+
+const (
+	// ExportJSONBase64 writes a JSON object per line, with the fields key
+	// and value holding the bytes in base64.
+	ExportJSONBase64 ExportFormat = iota
+	// ExportJSONEscaped writes a JSON object per line, with the fields key
+	// and value holding the bytes escaped by EscapeBytes. It suits mostly
+	// textual data.
+	ExportJSONEscaped
+	// ExportCSV writes a CSV header line "key,value", then a record per
+	// pair, with the bytes escaped as in ExportJSONEscaped.
+	ExportCSV
+	// ExportBinary writes, for each pair, the length of the key as a
+	// uvarint, the key, the length of the value as a uvarint and the
+	// value.
+	ExportBinary
+)
+
+var exportFormatNames = []string{"jsonl-base64", "jsonl-escaped", "csv", "binary"}
+
+func (f ExportFormat) String() string {
+	if f < 0 || int(f) >= len(exportFormatNames) {
+		return "ExportFormat(" + strconv.Itoa(int(f)) + ")"
+	}
+	return exportFormatNames[f]
+}
+
+// ParseExportFormat returns the ExportFormat with the given name, as
+// returned by its String method.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	for i, n := range exportFormatNames {
+		if n == name {
+			return ExportFormat(i), nil
+		}
+	}
+	return 0, fmt.Errorf("rocksgo: unknown export format %q", name)
+}
+
+// exportRecord is a line of the JSON formats. Encoding/json writes []byte
+// fields in base64.
+type exportRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type escapedRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Export writes the key-value pairs of the database in r, in order, to w.
+// The bounds of r are compared bytewise, as with DB.Range, and either may
+// be nil. It returns the number of pairs written. If reading fails, the
+// pairs counted are written to w before Export returns the error.
+//
+// Export reads with a single Iterator, through ro, and writes as it reads,
+// so that its memory use does not depend on the size of the data. Setting a
+// Snapshot on ro, and SetFillCache(false), is advisable when exporting from
+// a database serving other reads.
+func Export(db *DB, ro *ReadOptions, r Range, w io.Writer, format ExportFormat) (int, error) {
+	if format < 0 || int(format) >= len(exportFormatNames) {
+		return 0, fmt.Errorf("rocksgo: unknown export format %v", format)
+	}
+	bw := bufio.NewWriter(w)
+	var cw *csv.Writer
+	if format == ExportCSV {
+		cw = csv.NewWriter(bw)
+		if err := cw.Write([]string{"key", "value"}); err != nil {
+			return 0, err
+		}
+	}
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	var lenBuf [binary.MaxVarintLen64]byte
+
+	n := 0
+	var iterErr, err error
+	for k, v := range db.Range(ro, r.Start, r.Limit, &iterErr) {
+		switch format {
+		case ExportJSONBase64:
+			err = enc.Encode(exportRecord{k, v})
+		case ExportJSONEscaped:
+			err = enc.Encode(escapedRecord{EscapeBytes(k), EscapeBytes(v)})
+		case ExportCSV:
+			err = cw.Write([]string{EscapeBytes(k), EscapeBytes(v)})
+		case ExportBinary:
+			bw.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(k)))])
+			bw.Write(k)
+			bw.Write(lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(v)))])
+			_, err = bw.Write(v)
+		}
+		if err != nil {
+			break
+		}
+		n++
+	}
+	if err == nil {
+		err = iterErr
+	}
+	// Flush even on failure, so that the pairs counted in n reach w; an
+	// error writing them is then the one already in err.
+	if cw != nil {
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	}
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return n, err
+}
+
+// Import reads key-value pairs written by Export in the given format from r,
+// and puts them in the database, through wo. It returns the number of pairs
+// imported.
+//
+// The pairs are written in WriteBatches of batchSize pairs, so that its
+// memory use is bounded by batchSize and the size of the pairs. Each batch
+// is atomic, but the import as a whole is not: if it fails, the batches
+// written before the failure remain. A batchSize of zero or less writes
+// batches of 1000 pairs.
+func Import(db *DB, wo *WriteOptions, r io.Reader, format ExportFormat, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	next, err := importReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+	wb := NewWriteBatch()
+	defer wb.Close()
+	n, pending := 0, 0
+	for {
+		k, v, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, fmt.Errorf("rocksgo: reading pair %d: %w", n+pending+1, err)
+		}
+		wb.Put(k, v)
+		pending++
+		if pending == batchSize {
+			if err := db.Write(wo, wb); err != nil {
+				return n, err
+			}
+			wb.Clear()
+			n, pending = n+pending, 0
+		}
+	}
+	if pending > 0 {
+		if err := db.Write(wo, wb); err != nil {
+			return n, err
+		}
+	}
+	return n + pending, nil
+}
+
+// importReader returns a function reading the next pair from r, or io.EOF
+// once there are no more.
+func importReader(r io.Reader, format ExportFormat) (func() ([]byte, []byte, error), error) {
+	br := bufio.NewReader(r)
+	switch format {
+	case ExportJSONBase64:
+		dec := json.NewDecoder(br)
+		return func() ([]byte, []byte, error) {
+			var rec exportRecord
+			if err := dec.Decode(&rec); err != nil {
+				return nil, nil, err
+			}
+			return rec.Key, rec.Value, nil
+		}, nil
+	case ExportJSONEscaped:
+		dec := json.NewDecoder(br)
+		return func() ([]byte, []byte, error) {
+			var rec escapedRecord
+			if err := dec.Decode(&rec); err != nil {
+				return nil, nil, err
+			}
+			return unescapePair(rec.Key, rec.Value)
+		}, nil
+	case ExportCSV:
+		cr := csv.NewReader(br)
+		cr.FieldsPerRecord = 2
+		cr.ReuseRecord = true
+		header, err := cr.Read()
+		if err == io.EOF {
+			return func() ([]byte, []byte, error) { return nil, nil, io.EOF }, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header[0] != "key" || header[1] != "value" {
+			return nil, fmt.Errorf("rocksgo: CSV header is %q, want \"key,value\"", header)
+		}
+		return func() ([]byte, []byte, error) {
+			rec, err := cr.Read()
+			if err != nil {
+				return nil, nil, err
+			}
+			return unescapePair(rec[0], rec[1])
+		}, nil
+	case ExportBinary:
+		readBytes := func() ([]byte, error) {
+			l, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			// Read through a LimitReader rather than allocating l bytes up
+			// front, so that a corrupt length fails rather than exhausting
+			// memory.
+			b, err := io.ReadAll(io.LimitReader(br, int64(l)))
+			if err == nil && uint64(len(b)) != l {
+				err = io.ErrUnexpectedEOF
+			}
+			return b, err
+		}
+		return func() ([]byte, []byte, error) {
+			k, err := readBytes()
+			if err != nil {
+				return nil, nil, err
+			}
+			v, err := readBytes()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return k, v, err
+		}, nil
+	}
+	return nil, fmt.Errorf("rocksgo: unknown export format %v", format)
+}
+
+func unescapePair(key, value string) ([]byte, []byte, error) {
+	k, err := UnescapeBytes(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := UnescapeBytes(value)
+	return k, v, err
+}
+
+// EscapeBytes returns b as text, with printable ASCII as is, and every other
+// byte, and the backslash, as a Go escape sequence such as \x00, \n or \\.
+// The text is ASCII, even where b holds UTF-8.
+func EscapeBytes(b []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for _, c := range b {
+		switch {
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c >= 0x20 && c < 0x7f:
+			sb.WriteByte(c)
+		default:
+			// Quoting the byte alone escapes it as \xNN even when it is
+			// part of a UTF-8 sequence.
+			q := strconv.Quote(string([]byte{c}))
+			sb.WriteString(q[1 : len(q)-1])
+		}
+	}
+	return sb.String()
+}
+
+// UnescapeBytes reverses EscapeBytes. It also accepts the other escape
+// sequences of Go strings, such as \u00e9, and bytes other than printable
+// ASCII as is.
+func UnescapeBytes(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for len(s) > 0 {
+		if s[0] != '\\' {
+			b = append(b, s[0])
+			s = s[1:]
+			continue
+		}
+		// UnquoteChar takes \x escapes as bytes, but other escapes, such as
+		// \u, as runes to be encoded in UTF-8.
+		r, multibyte, tail, err := strconv.UnquoteChar(s, 0)
+		if err != nil {
+			return nil, fmt.Errorf("rocksgo: invalid escape sequence in %q", s)
+		}
+		if multibyte {
+			b = utf8.AppendRune(b, r)
+		} else {
+			b = append(b, byte(r))
+		}
+		s = tail
+	}
+	return b, nil
+}
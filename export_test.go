@@ -0,0 +1,115 @@
+package rocksgo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	src, ro, wo := openBinaryKeysDb(t)
+	want := map[string]string{}
+	for i := 0; i < 20; i++ {
+		k := fmt.Sprintf("key%02d", i)
+		v := fmt.Sprintf("v,\"%d\"\n\\\x00\xff", i)
+		if i == 7 {
+			v = ""
+		}
+		if err := src.Put(wo, []byte(k), []byte(v)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if i >= 5 && i < 15 {
+			want[k] = v
+		}
+	}
+	r := Range{Start: []byte("key05"), Limit: []byte("key15")}
+
+	for _, format := range []ExportFormat{ExportJSONBase64, ExportJSONEscaped, ExportCSV, ExportBinary} {
+		var buf bytes.Buffer
+		n, err := Export(src, ro, r, &buf, format)
+		if err != nil || n != len(want) {
+			t.Fatalf("%v: Export = %d, %v, want %d", format, n, err, len(want))
+		}
+		if format != ExportBinary && strings.Count(buf.String(), "\n") < len(want) {
+			t.Errorf("%v: not one line per pair:\n%s", format, buf.String())
+		}
+
+		dst, _, _ := openBinaryKeysDb(t)
+		n, err = Import(dst, wo, &buf, format, 3)
+		if err != nil || n != len(want) {
+			t.Fatalf("%v: Import = %d, %v, want %d", format, n, err, len(want))
+		}
+		got := map[string]string{}
+		for k, v := range dst.Range(ro, nil, nil, &err) {
+			got[string(k)] = string(v)
+		}
+		if err != nil {
+			t.Fatalf("%v: Range failed: %v", format, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%v: imported %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestExportFormatNames(t *testing.T) {
+	for _, f := range []ExportFormat{ExportJSONBase64, ExportJSONEscaped, ExportCSV, ExportBinary} {
+		got, err := ParseExportFormat(f.String())
+		if err != nil || got != f {
+			t.Errorf("ParseExportFormat(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+	if _, err := ParseExportFormat("xml"); err == nil {
+		t.Errorf("ParseExportFormat accepted an unknown format")
+	}
+}
+
+func TestEscapeBytes(t *testing.T) {
+	data := []byte("a\\b\x00\n\xff\xc3\xa9z")
+	s := EscapeBytes(data)
+	if s != `a\\b\x00\n\xff\xc3\xa9z` {
+		t.Errorf("EscapeBytes = %s", s)
+	}
+	if got, err := UnescapeBytes(s); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("UnescapeBytes(%q) = %q, %v, want %q", s, got, err, data)
+	}
+	if got, err := UnescapeBytes(`é\t`); err != nil || string(got) != "é\t" {
+		t.Errorf("UnescapeBytes(`é\\t`) = %q, %v", got, err)
+	}
+	if _, err := UnescapeBytes(`\q`); err == nil {
+		t.Errorf("an invalid escape was unescaped")
+	}
+}
+
+func TestImportReaderErrors(t *testing.T) {
+	if _, err := importReader(strings.NewReader("k,v\n"), ExportCSV); err == nil {
+		t.Errorf("a CSV header other than key,value was accepted")
+	}
+	next, err := importReader(strings.NewReader(""), ExportCSV)
+	if err != nil {
+		t.Fatalf("importReader failed on empty CSV: %v", err)
+	}
+	if _, _, err := next(); err != io.EOF {
+		t.Errorf("reading empty CSV = %v, want io.EOF", err)
+	}
+
+	// A key of 3 bytes, then a value of 5 bytes of which only 2 are there.
+	next, err = importReader(strings.NewReader("\x03abc\x05de"), ExportBinary)
+	if err != nil {
+		t.Fatalf("importReader failed: %v", err)
+	}
+	if _, _, err := next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("reading a truncated pair = %v, want io.ErrUnexpectedEOF", err)
+	}
+	// A pair cut between the key and the value.
+	next, _ = importReader(strings.NewReader("\x01a"), ExportBinary)
+	if _, _, err := next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("reading a pair without value = %v, want io.ErrUnexpectedEOF", err)
+	}
+	next, _ = importReader(strings.NewReader(`{"key":"\\q","value":""}`), ExportJSONEscaped)
+	if _, _, err := next(); err == nil {
+		t.Errorf("an invalid escape was imported")
+	}
+}
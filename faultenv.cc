@@ -0,0 +1,368 @@
+// A fault-injecting Env, wrapping the default one, for testing how
+// databases and the code using them cope with crashes and I/O errors.
+
+#include <errno.h>
+#include <unistd.h>
+
+#include <map>
+#include <memory>
+#include <mutex>
+#include <random>
+#include <string>
+
+#include "rocksgo_internal.h"
+
+using rocksdb::EnvOptions;
+using rocksdb::EnvWrapper;
+using rocksdb::RandomAccessFile;
+using rocksdb::RandomAccessFileWrapper;
+using rocksdb::ReadRequest;
+using rocksdb::SequentialFile;
+using rocksdb::SequentialFileWrapper;
+using rocksdb::Slice;
+using rocksdb::Status;
+using rocksdb::WritableFile;
+using rocksdb::WritableFileWrapper;
+
+namespace {
+
+// FaultState holds the faults to inject, and what the files being written
+// have synced, shared by a FaultEnv and the files it opens.
+class FaultState {
+ public:
+  explicit FaultState(uint64_t seed) : rng_(seed) {
+    for (int op = 0; op < ROCKSGO_FAULT_OPS; op++) {
+      remaining_[op] = -1;
+      probability_[op] = 0;
+    }
+  }
+
+  // Check returns the error to inject into an operation, or OK.
+  Status Check(int op) {
+    std::lock_guard<std::mutex> l(mu_);
+    if (!active_) {
+      return Status::IOError("filesystem inactive");
+    }
+    if (remaining_[op] == 0) {
+      return Status::IOError("injected fault");
+    }
+    if (remaining_[op] > 0) {
+      remaining_[op]--;
+    }
+    if (probability_[op] > 0 &&
+        std::uniform_real_distribution<double>(0, 1)(rng_) < probability_[op]) {
+      return Status::IOError("injected fault");
+    }
+    return Status::OK();
+  }
+
+  // Append returns the error to inject into a write of n bytes, or OK,
+  // using up that much free space.
+  Status Append(size_t n) {
+    Status s = Check(ROCKSGO_FAULT_WRITE);
+    if (!s.ok()) {
+      return s;
+    }
+    std::lock_guard<std::mutex> l(mu_);
+    if (free_space_ >= 0) {
+      if (static_cast<uint64_t>(free_space_) < n) {
+        return Status::NoSpace();
+      }
+      free_space_ -= n;
+    }
+    return Status::OK();
+  }
+
+  void SetActive(bool active) {
+    std::lock_guard<std::mutex> l(mu_);
+    active_ = active;
+  }
+
+  void FailAfter(int op, int64_t n) {
+    std::lock_guard<std::mutex> l(mu_);
+    remaining_[op] = n;
+  }
+
+  void FailWithProbability(int op, double p) {
+    std::lock_guard<std::mutex> l(mu_);
+    probability_[op] = p;
+  }
+
+  void SetFreeSpace(int64_t bytes) {
+    std::lock_guard<std::mutex> l(mu_);
+    free_space_ = bytes;
+  }
+
+  void Synced(const std::string& fname, uint64_t size) {
+    std::lock_guard<std::mutex> l(mu_);
+    synced_[fname] = size;
+  }
+
+  void Deleted(const std::string& fname) {
+    std::lock_guard<std::mutex> l(mu_);
+    synced_.erase(fname);
+  }
+
+  void Renamed(const std::string& src, const std::string& target) {
+    std::lock_guard<std::mutex> l(mu_);
+    auto it = synced_.find(src);
+    if (it != synced_.end()) {
+      synced_[target] = it->second;
+      synced_.erase(it);
+    }
+  }
+
+  // DropUnsyncedData truncates every file written through the Env to the
+  // size it had when last synced.
+  Status DropUnsyncedData() {
+    std::lock_guard<std::mutex> l(mu_);
+    for (const auto& f : synced_) {
+      if (truncate(f.first.c_str(), static_cast<off_t>(f.second)) != 0 &&
+          errno != ENOENT) {
+        return Status::IOError("truncating " + f.first, strerror(errno));
+      }
+    }
+    return Status::OK();
+  }
+
+ private:
+  std::mutex mu_;
+  std::mt19937_64 rng_;
+  bool active_ = true;
+  int64_t remaining_[ROCKSGO_FAULT_OPS];
+  double probability_[ROCKSGO_FAULT_OPS];
+  int64_t free_space_ = -1;
+  std::map<std::string, uint64_t> synced_;
+};
+
+class FaultWritableFile : public WritableFileWrapper {
+ public:
+  FaultWritableFile(std::unique_ptr<WritableFile>&& f, const std::string& fname,
+                    uint64_t size, FaultState* state)
+      : WritableFileWrapper(f.get()),
+        file_(std::move(f)),
+        fname_(fname),
+        size_(size),
+        state_(state) {
+    state_->Synced(fname_, size_);
+  }
+
+  Status Append(const Slice& data) override {
+    Status s = state_->Append(data.size());
+    if (s.ok()) {
+      s = WritableFileWrapper::Append(data);
+    }
+    if (s.ok()) {
+      size_ += data.size();
+    }
+    return s;
+  }
+
+  Status Append(const Slice& data,
+                const rocksdb::DataVerificationInfo& info) override {
+    Status s = state_->Append(data.size());
+    if (s.ok()) {
+      s = WritableFileWrapper::Append(data, info);
+    }
+    if (s.ok()) {
+      size_ += data.size();
+    }
+    return s;
+  }
+
+  Status Sync() override { return SyncWith(&WritableFileWrapper::Sync); }
+  Status Fsync() override { return SyncWith(&WritableFileWrapper::Fsync); }
+
+ private:
+  Status SyncWith(Status (WritableFileWrapper::*sync)()) {
+    Status s = state_->Check(ROCKSGO_FAULT_SYNC);
+    if (s.ok()) {
+      s = (this->*sync)();
+    }
+    if (s.ok()) {
+      state_->Synced(fname_, size_);
+    }
+    return s;
+  }
+
+  std::unique_ptr<WritableFile> file_;
+  std::string fname_;
+  uint64_t size_;
+  FaultState* state_;
+};
+
+class FaultSequentialFile : public SequentialFileWrapper {
+ public:
+  FaultSequentialFile(std::unique_ptr<SequentialFile>&& f, FaultState* state)
+      : SequentialFileWrapper(f.get()), file_(std::move(f)), state_(state) {}
+
+  Status Read(size_t n, Slice* result, char* scratch) override {
+    Status s = state_->Check(ROCKSGO_FAULT_READ);
+    return s.ok() ? SequentialFileWrapper::Read(n, result, scratch) : s;
+  }
+
+ private:
+  std::unique_ptr<SequentialFile> file_;
+  FaultState* state_;
+};
+
+class FaultRandomAccessFile : public RandomAccessFileWrapper {
+ public:
+  FaultRandomAccessFile(std::unique_ptr<RandomAccessFile>&& f,
+                        FaultState* state)
+      : RandomAccessFileWrapper(f.get()), file_(std::move(f)), state_(state) {}
+
+  Status Read(uint64_t offset, size_t n, Slice* result,
+              char* scratch) const override {
+    Status s = state_->Check(ROCKSGO_FAULT_READ);
+    return s.ok() ? RandomAccessFileWrapper::Read(offset, n, result, scratch)
+                  : s;
+  }
+
+  Status MultiRead(ReadRequest* reqs, size_t num_reqs) override {
+    Status s = state_->Check(ROCKSGO_FAULT_READ);
+    return s.ok() ? RandomAccessFileWrapper::MultiRead(reqs, num_reqs) : s;
+  }
+
+ private:
+  std::unique_ptr<RandomAccessFile> file_;
+  FaultState* state_;
+};
+
+class FaultEnv : public EnvWrapper {
+ public:
+  explicit FaultEnv(uint64_t seed)
+      : EnvWrapper(rocksdb::Env::Default()), state_(seed) {}
+
+  const char* Name() const override { return "rocksgo.FaultEnv"; }
+
+  FaultState* state() { return &state_; }
+
+  Status NewWritableFile(const std::string& fname,
+                         std::unique_ptr<WritableFile>* result,
+                         const EnvOptions& options) override {
+    Status s = state_.Check(ROCKSGO_FAULT_WRITE);
+    std::unique_ptr<WritableFile> f;
+    if (s.ok()) {
+      s = EnvWrapper::NewWritableFile(fname, &f, options);
+    }
+    if (s.ok()) {
+      result->reset(new FaultWritableFile(std::move(f), fname, 0, &state_));
+    }
+    return s;
+  }
+
+  Status ReopenWritableFile(const std::string& fname,
+                            std::unique_ptr<WritableFile>* result,
+                            const EnvOptions& options) override {
+    Status s = state_.Check(ROCKSGO_FAULT_WRITE);
+    uint64_t size = 0;
+    if (s.ok() && FileExists(fname).ok()) {
+      s = GetFileSize(fname, &size);
+    }
+    std::unique_ptr<WritableFile> f;
+    if (s.ok()) {
+      s = EnvWrapper::ReopenWritableFile(fname, &f, options);
+    }
+    if (s.ok()) {
+      result->reset(new FaultWritableFile(std::move(f), fname, size, &state_));
+    }
+    return s;
+  }
+
+  Status NewSequentialFile(const std::string& fname,
+                           std::unique_ptr<SequentialFile>* result,
+                           const EnvOptions& options) override {
+    Status s = state_.Check(ROCKSGO_FAULT_READ);
+    std::unique_ptr<SequentialFile> f;
+    if (s.ok()) {
+      s = EnvWrapper::NewSequentialFile(fname, &f, options);
+    }
+    if (s.ok()) {
+      result->reset(new FaultSequentialFile(std::move(f), &state_));
+    }
+    return s;
+  }
+
+  Status NewRandomAccessFile(const std::string& fname,
+                             std::unique_ptr<RandomAccessFile>* result,
+                             const EnvOptions& options) override {
+    Status s = state_.Check(ROCKSGO_FAULT_READ);
+    std::unique_ptr<RandomAccessFile> f;
+    if (s.ok()) {
+      s = EnvWrapper::NewRandomAccessFile(fname, &f, options);
+    }
+    if (s.ok()) {
+      result->reset(new FaultRandomAccessFile(std::move(f), &state_));
+    }
+    return s;
+  }
+
+  Status DeleteFile(const std::string& fname) override {
+    Status s = state_.Check(ROCKSGO_FAULT_WRITE);
+    if (s.ok()) {
+      s = EnvWrapper::DeleteFile(fname);
+    }
+    if (s.ok()) {
+      state_.Deleted(fname);
+    }
+    return s;
+  }
+
+  Status RenameFile(const std::string& src,
+                    const std::string& target) override {
+    Status s = state_.Check(ROCKSGO_FAULT_WRITE);
+    if (s.ok()) {
+      s = EnvWrapper::RenameFile(src, target);
+    }
+    if (s.ok()) {
+      state_.Renamed(src, target);
+    }
+    return s;
+  }
+
+ private:
+  FaultState state_;
+};
+
+FaultState* StateOf(rocksdb_env_t* env) {
+  return static_cast<FaultEnv*>(env->rep)->state();
+}
+
+}  // namespace
+
+extern "C" {
+
+rocksdb_env_t* rocksgo_fault_env_create(uint64_t seed) {
+  rocksdb_env_t* env = new rocksdb_env_t;
+  env->rep = new FaultEnv(seed);
+  env->is_default = false;
+  return env;
+}
+
+void rocksgo_fault_env_set_filesystem_active(rocksdb_env_t* env,
+                                             unsigned char active) {
+  StateOf(env)->SetActive(active);
+}
+
+void rocksgo_fault_env_fail_after(rocksdb_env_t* env, int op, int64_t n) {
+  StateOf(env)->FailAfter(op, n);
+}
+
+void rocksgo_fault_env_fail_with_probability(rocksdb_env_t* env, int op,
+                                             double p) {
+  StateOf(env)->FailWithProbability(op, p);
+}
+
+void rocksgo_fault_env_set_free_space(rocksdb_env_t* env, int64_t bytes) {
+  StateOf(env)->SetFreeSpace(bytes);
+}
+
+void rocksgo_fault_env_drop_unsynced_data(rocksdb_env_t* env, char** errptr) {
+  Status s = StateOf(env)->DropUnsyncedData();
+  if (!s.ok()) {
+    rocksgo::SaveError(errptr, s);
+  }
+}
+
+}  // extern "C"
@@ -0,0 +1,88 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+// FaultOp is a kind of file system operation a FaultEnv injects errors
+// into.
+type FaultOp int
+
+const (
+	// FaultWrite covers creating, appending to, renaming and deleting
+	// files.
+	FaultWrite = FaultOp(C.ROCKSGO_FAULT_WRITE)
+	// FaultRead covers opening files for reading, and reading them.
+	FaultRead = FaultOp(C.ROCKSGO_FAULT_READ)
+	// FaultSync covers syncing files.
+	FaultSync = FaultOp(C.ROCKSGO_FAULT_SYNC)
+)
+
+// FaultEnv is an Env on the local file system that can simulate crashes
+// and I/O errors, for testing how a database, and the code using it, cope
+// with them. It is set on an Options with SetEnv(fe.Env).
+//
+// Injected errors are IOErrors. Random errors are drawn from a generator
+// seeded by NewFaultEnv, so a test whose file operations happen in the same
+// order sees the same failures on every run; the background flushes and
+// compactions of rocksdb can upset that order, unless they are kept out of
+// the way.
+//
+// A typical crash test writes, then simulates a power loss:
+//
+//	fe.SetFilesystemActive(false)
+//	db.Close()
+//	fe.DropUnsyncedData()
+//	fe.SetFilesystemActive(true)
+//	db, err = rocksgo.Open(dbname, options)
+//
+// To prevent memory leaks, Close must be called on a FaultEnv when it is no
+// longer needed by the program, after closing the databases using it.
+type FaultEnv struct {
+	*Env
+}
+
+// NewFaultEnv creates a FaultEnv injecting no fault until told to, whose
+// random faults are drawn from a generator seeded with seed.
+func NewFaultEnv(seed int64) *FaultEnv {
+	env := &Env{Env: C.rocksgo_fault_env_create(C.uint64_t(seed))}
+	env.leak = trackResource(env, "Env", nil)
+	return &FaultEnv{env}
+}
+
+// SetFilesystemActive controls whether the file system accepts any
+// operation. Once inactive, every operation fails, as after a crash, so
+// that nothing more reaches the disk.
+func (fe *FaultEnv) SetFilesystemActive(active bool) {
+	C.rocksgo_fault_env_set_filesystem_active(fe.Env.Env, boolToUchar(active))
+}
+
+// FailAfter lets n more operations of kind op succeed, and fails every one
+// after them. A negative n stops failing them.
+func (fe *FaultEnv) FailAfter(op FaultOp, n int) {
+	C.rocksgo_fault_env_fail_after(fe.Env.Env, C.int(op), C.int64_t(n))
+}
+
+// FailWithProbability fails each operation of kind op with probability p.
+// A p of zero stops failing them.
+func (fe *FaultEnv) FailWithProbability(op FaultOp, p float64) {
+	C.rocksgo_fault_env_fail_with_probability(fe.Env.Env, C.int(op), C.double(p))
+}
+
+// SetFreeSpace simulates a disk with bytes of free space left, failing the
+// writes beyond it with an error matching ErrNoSpace. A negative bytes
+// makes the space unlimited again.
+func (fe *FaultEnv) SetFreeSpace(bytes int64) {
+	C.rocksgo_fault_env_set_free_space(fe.Env.Env, C.int64_t(bytes))
+}
+
+// DropUnsyncedData truncates every file written through the FaultEnv to the
+// size it had when it was last synced, losing the data a power loss would.
+// It should only be called once the databases using the FaultEnv are
+// closed, typically after SetFilesystemActive(false).
+func (fe *FaultEnv) DropUnsyncedData() error {
+	var errStr *C.char
+	C.rocksgo_fault_env_drop_unsynced_data(fe.Env.Env, &errStr)
+	return statusError(errStr)
+}
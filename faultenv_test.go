@@ -0,0 +1,119 @@
+package rocksgo
+
+import (
+	"errors"
+	"testing"
+)
+
+func openFaultDb(t *testing.T, fe *FaultEnv) (*DB, *Options, string) {
+	dbname := tempDir(t)
+	options := NewOptions()
+	options.SetCreateIfMissing(true)
+	options.SetEnv(fe.Env)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		options.Close()
+		fe.Close()
+		deleteDBDirectory(t, dbname)
+	})
+	return db, options, dbname
+}
+
+func TestFaultEnvDropUnsyncedData(t *testing.T) {
+	fe := NewFaultEnv(1)
+	db, options, dbname := openFaultDb(t, fe)
+	ro := NewReadOptions()
+	defer ro.Close()
+	synced := NewWriteOptions()
+	defer synced.Close()
+	synced.SetSync(true)
+	unsynced := NewWriteOptions()
+	defer unsynced.Close()
+
+	if err := db.Put(synced, []byte("durable"), []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := db.Put(unsynced, []byte("lost"), []byte("2")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	fe.SetFilesystemActive(false)
+	db.Close()
+	if err := fe.DropUnsyncedData(); err != nil {
+		t.Fatalf("DropUnsyncedData failed: %v", err)
+	}
+	fe.SetFilesystemActive(true)
+
+	db2, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be reopened after the crash: %v", err)
+	}
+	defer db2.Close()
+	if v, err := db2.Get(ro, []byte("durable")); err != nil || string(v) != "1" {
+		t.Errorf("the synced write was lost: %q, %v", v, err)
+	}
+	if v, err := db2.Get(ro, []byte("lost")); err != nil || v != nil {
+		t.Errorf("the unsynced write survived the crash: %q, %v", v, err)
+	}
+}
+
+func TestFaultEnvFailAfter(t *testing.T) {
+	fe := NewFaultEnv(1)
+	db, _, _ := openFaultDb(t, fe)
+	wo := NewWriteOptions()
+	defer wo.Close()
+
+	fe.FailAfter(FaultWrite, 1)
+	if err := db.Put(wo, []byte("a"), []byte("1")); err != nil {
+		t.Fatalf("the first write should succeed: %v", err)
+	}
+	if err := db.Put(wo, []byte("b"), []byte("2")); !IsIOError(err) {
+		t.Errorf("the second write returned %v, want an IOError", err)
+	}
+}
+
+func TestFaultEnvDiskFull(t *testing.T) {
+	fe := NewFaultEnv(1)
+	db, _, _ := openFaultDb(t, fe)
+	wo := NewWriteOptions()
+	defer wo.Close()
+
+	fe.SetFreeSpace(0)
+	if err := db.Put(wo, []byte("a"), []byte("1")); !errors.Is(err, ErrNoSpace) {
+		t.Errorf("writing to a full disk returned %v, want ErrNoSpace", err)
+	}
+}
+
+func TestFaultEnvSeeded(t *testing.T) {
+	// The same seed fails the same writes.
+	failures := func() []bool {
+		fe := NewFaultEnv(42)
+		db, _, _ := openFaultDb(t, fe)
+		wo := NewWriteOptions()
+		defer wo.Close()
+		wo.SetSync(true)
+		fe.FailWithProbability(FaultSync, 0.5)
+		var failed []bool
+		for i := 0; i < 10; i++ {
+			err := db.Put(wo, []byte("k"), []byte("v"))
+			failed = append(failed, err != nil)
+			if err != nil {
+				break
+			}
+		}
+		return failed
+	}
+	a, b := failures(), failures()
+	if len(a) != len(b) {
+		t.Fatalf("runs with the same seed diverged: %v and %v", a, b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("runs with the same seed diverged: %v and %v", a, b)
+		}
+	}
+}
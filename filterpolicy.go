@@ -16,6 +16,7 @@ import "C"
 // it is no longer needed by the program.
 type FilterPolicy struct {
 	Policy *C.rocksdb_filterpolicy_t
+	leak   *leakRecord
 }
 
 // NewBloomFilter creates a filter policy that will create a bloom filter when
@@ -23,10 +24,19 @@ type FilterPolicy struct {
 //
 // See the FilterPolicy documentation for more.
 func NewBloomFilter(bitsPerKey int) *FilterPolicy {
-	policy := C.rocksdb_filterpolicy_create_bloom(C.int(bitsPerKey))
-	return &FilterPolicy{policy}
+	fp := &FilterPolicy{Policy: C.rocksdb_filterpolicy_create_bloom(C.int(bitsPerKey))}
+	fp.leak = trackResource(fp, "FilterPolicy", nil)
+	return fp
 }
 
+// Close deallocates the FilterPolicy, freeing the underlying struct.
+//
+// Closing a FilterPolicy more than once is a no-op.
 func (fp *FilterPolicy) Close() {
+	if fp.Policy == nil {
+		return
+	}
 	C.rocksdb_filterpolicy_destroy(fp.Policy)
+	fp.Policy = nil
+	fp.leak.untrack()
 }
@@ -0,0 +1,277 @@
+// Package indexed maintains secondary indexes over the rows of a rocksgo
+// database.
+//
+// A Table stores rows, key-value pairs, along with an entry in each of its
+// Indexes for every index value computed from a row. Puts and Deletes update
+// a row and its index entries atomically, and queries look rows up by index
+// value from a Snapshot, so that they never see index entries and rows out
+// of step.
+//
+//	byEmail := indexed.Index{Name: "email", Func: func(key, value []byte) [][]byte {
+//		return [][]byte{emailOf(value)}
+//	}}
+//	users := indexed.New(db, "users", byEmail)
+//	defer users.Close()
+//	err := users.Put(wo, userID, userRecord)
+//	...
+//	var err error
+//	for id, record := range users.Lookup("email", []byte("ann@example.com"), &err) {
+//		...
+//	}
+//
+// Rows and index entries are stored under keys encoded with package keys,
+// starting with the name of the Table. Only the Table may write them: a
+// Table reads a row back before updating it to find the index entries to
+// remove, and serializes its own updates of a row to keep that read
+// current.
+package indexed
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"sync"
+
+	"github.com/ananclub/rocksgo"
+	"github.com/ananclub/rocksgo/internal/scanerr"
+	"github.com/ananclub/rocksgo/keys"
+)
+
+// Index declares an index of a Table.
+type Index struct {
+	// Name identifies the index in queries. It is part of the keys of the
+	// index entries, so renaming an index orphans them.
+	Name string
+	// Func returns the index values of a row. A row may have any number of
+	// index values, none included. Func must be deterministic: the index
+	// entries of a row are removed by calling it again on the old row.
+	Func func(key, value []byte) [][]byte
+}
+
+// ErrUnknownIndex is returned by queries naming an index the Table does not
+// have.
+var ErrUnknownIndex = errors.New("indexed: unknown index")
+
+// lockStripes is the number of locks the updates of rows are serialized on,
+// by hash of their keys.
+const lockStripes = 256
+
+// Table is a set of rows in a DB, with their secondary indexes. It may be
+// used from several goroutines at once.
+//
+// To prevent memory leaks, Close must be called on a Table when the program
+// no longer needs it.
+type Table struct {
+	db      *rocksgo.DB
+	name    string
+	indexes []Index
+	ro      *rocksgo.ReadOptions
+	seed    maphash.Seed
+	locks   [lockStripes]sync.Mutex
+}
+
+// New returns the Table of db named name, with the indexes given. Tables
+// sharing a DB must have different names.
+//
+// Adding an index to a Table that already has rows does not index them;
+// they have to be put again.
+func New(db *rocksgo.DB, name string, indexes ...Index) *Table {
+	return &Table{
+		db:      db,
+		name:    name,
+		indexes: indexes,
+		ro:      rocksgo.NewReadOptions(),
+		seed:    maphash.MakeSeed(),
+	}
+}
+
+// Close releases the resources of the Table.
+func (t *Table) Close() {
+	t.ro.Close()
+}
+
+// The keys of rows are (name, "r", key), and those of index entries
+// (name, "i", index name, index value, key), with an empty value.
+
+func (t *Table) rowKey(key []byte) []byte {
+	k, _ := keys.Encode(t.name, "r", key)
+	return k
+}
+
+func (t *Table) entryKey(index string, value, key []byte) []byte {
+	k, _ := keys.Encode(t.name, "i", index, value, key)
+	return k
+}
+
+// entries returns the keys of the index entries of a row.
+func (t *Table) entries(key, value []byte) map[string]bool {
+	m := make(map[string]bool)
+	for _, idx := range t.indexes {
+		for _, v := range idx.Func(key, value) {
+			m[string(t.entryKey(idx.Name, v, key))] = true
+		}
+	}
+	return m
+}
+
+func (t *Table) lock(key []byte) *sync.Mutex {
+	return &t.locks[maphash.Bytes(t.seed, key)%lockStripes]
+}
+
+// Get returns the row stored under key, or nil if there is none.
+func (t *Table) Get(ro *rocksgo.ReadOptions, key []byte) ([]byte, error) {
+	return t.db.Get(ro, t.rowKey(key))
+}
+
+// Put stores value under key, updating the index entries of the row in the
+// same write.
+func (t *Table) Put(wo *rocksgo.WriteOptions, key, value []byte) error {
+	return t.update(wo, key, value, true)
+}
+
+// Delete removes the row stored under key, along with its index entries.
+func (t *Table) Delete(wo *rocksgo.WriteOptions, key []byte) error {
+	return t.update(wo, key, nil, false)
+}
+
+func (t *Table) update(wo *rocksgo.WriteOptions, key, value []byte, put bool) error {
+	mu := t.lock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	rowKey := t.rowKey(key)
+	old, err := t.db.Get(t.ro, rowKey)
+	if err != nil {
+		return err
+	}
+	var oldEntries, newEntries map[string]bool
+	if old != nil {
+		oldEntries = t.entries(key, old)
+	}
+	if put {
+		newEntries = t.entries(key, value)
+	}
+
+	wb := rocksgo.NewWriteBatch()
+	defer wb.Close()
+	for e := range oldEntries {
+		if !newEntries[e] {
+			wb.Delete([]byte(e))
+		}
+	}
+	for e := range newEntries {
+		if !oldEntries[e] {
+			wb.Put([]byte(e), nil)
+		}
+	}
+	if put {
+		wb.Put(rowKey, value)
+	} else {
+		wb.Delete(rowKey)
+	}
+	return t.db.Write(wo, wb)
+}
+
+// Lookup returns the rows having value as an index value of index, in the
+// order of their keys. Errors are reported through errp as for
+// rocksgo.DB.All.
+func (t *Table) Lookup(index string, value []byte, errp *error) iter.Seq2[[]byte, []byte] {
+	return t.query(index, errp, func() (rocksgo.Range, error) {
+		return keys.PrefixRange(t.name, "i", index, value)
+	})
+}
+
+// Range returns the rows having an index value of index from start up to
+// but not including limit, in the order of their index values, and then of
+// their keys. A row with several index values in the range is returned once
+// for each. A nil limit ends the range with the last index value. Errors
+// are reported through errp as for rocksgo.DB.All.
+func (t *Table) Range(index string, start, limit []byte, errp *error) iter.Seq2[[]byte, []byte] {
+	return t.query(index, errp, func() (rocksgo.Range, error) {
+		// An entry (name, "i", index, value, key) sorts after the tuple
+		// (name, "i", index, start) when value >= start, and before
+		// (name, "i", index, limit) when value < limit.
+		r, err := keys.PrefixRange(t.name, "i", index)
+		if err != nil {
+			return r, err
+		}
+		if r.Start, err = keys.Encode(t.name, "i", index, start); err != nil {
+			return r, err
+		}
+		if limit != nil {
+			r.Limit, err = keys.Encode(t.name, "i", index, limit)
+		}
+		return r, err
+	})
+}
+
+func (t *Table) query(index string, errp *error, bounds func() (rocksgo.Range, error)) iter.Seq2[[]byte, []byte] {
+	scanerr.Check(errp)
+	return func(yield func([]byte, []byte) bool) {
+		if !t.hasIndex(index) {
+			*errp = fmt.Errorf("%w %q", ErrUnknownIndex, index)
+			return
+		}
+		r, err := bounds()
+		if err != nil {
+			*errp = err
+			return
+		}
+
+		// The index entries and the rows they point at are read from the
+		// same Snapshot, so that they agree.
+		snap := t.db.NewSnapshot()
+		defer t.db.ReleaseSnapshot(snap)
+		ro := rocksgo.NewReadOptions()
+		defer ro.Close()
+		ro.SetSnapshot(snap)
+
+		var scanErr, rowErr error
+		for entry := range t.db.Range(ro, r.Start, r.Limit, &scanErr) {
+			var key, row []byte
+			if key, rowErr = entryRowKey(entry); rowErr != nil {
+				break
+			}
+			if row, rowErr = t.Get(ro, key); rowErr != nil {
+				break
+			}
+			if row == nil {
+				rowErr = fmt.Errorf("indexed: index entry %q of %s has no row", key, t.name)
+				break
+			}
+			if !yield(key, row) {
+				break
+			}
+		}
+		if rowErr != nil {
+			scanErr = rowErr
+		}
+		*errp = scanErr
+	}
+}
+
+func (t *Table) hasIndex(name string) bool {
+	for _, idx := range t.indexes {
+		if idx.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// entryRowKey returns the key of the row an index entry points at.
+func entryRowKey(entry []byte) ([]byte, error) {
+	elems, err := keys.Decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) != 5 {
+		return nil, fmt.Errorf("indexed: malformed index entry %q", entry)
+	}
+	key, ok := elems[4].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("indexed: malformed index entry %q", entry)
+	}
+	return key, nil
+}
@@ -0,0 +1,154 @@
+package indexed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ananclub/rocksgo"
+)
+
+func openTable(t *testing.T) (*Table, *rocksgo.DB, *rocksgo.ReadOptions, *rocksgo.WriteOptions) {
+	options := rocksgo.NewOptions()
+	options.SetCreateIfMissing(true)
+	db, err := rocksgo.Open(t.TempDir(), options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	ro := rocksgo.NewReadOptions()
+	wo := rocksgo.NewWriteOptions()
+	// Rows are "city,tag,tag..." and are indexed by city and by each tag.
+	byCity := Index{Name: "city", Func: func(key, value []byte) [][]byte {
+		return [][]byte{bytes.SplitN(value, []byte(","), 2)[0]}
+	}}
+	byTag := Index{Name: "tag", Func: func(key, value []byte) [][]byte {
+		return bytes.Split(value, []byte(","))[1:]
+	}}
+	tbl := New(db, "people", byCity, byTag)
+	t.Cleanup(func() {
+		tbl.Close()
+		db.Close()
+		options.Close()
+		ro.Close()
+		wo.Close()
+	})
+	return tbl, db, ro, wo
+}
+
+func keysOf(t *testing.T, seq iter.Seq2[[]byte, []byte], err *error) string {
+	var ks []string
+	for k := range seq {
+		ks = append(ks, string(k))
+	}
+	if *err != nil {
+		t.Errorf("query failed: %v", *err)
+	}
+	return strings.Join(ks, ",")
+}
+
+func TestTable(t *testing.T) {
+	tbl, _, ro, wo := openTable(t)
+	rows := map[string]string{
+		"ann": "paris,admin,dev",
+		"bob": "oslo,dev",
+		"cid": "paris",
+	}
+	for k, v := range rows {
+		if err := tbl.Put(wo, []byte(k), []byte(v)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if v, err := tbl.Get(ro, []byte("bob")); err != nil || string(v) != "oslo,dev" {
+		t.Errorf("Get(bob) = %q, %v", v, err)
+	}
+
+	var err error
+	for _, c := range []struct {
+		name string
+		seq  iter.Seq2[[]byte, []byte]
+		want string
+	}{
+		{"paris", tbl.Lookup("city", []byte("paris"), &err), "ann,cid"},
+		{"dev", tbl.Lookup("tag", []byte("dev"), &err), "ann,bob"},
+		{"missing", tbl.Lookup("tag", []byte("ops"), &err), ""},
+		{"cities a-p", tbl.Range("city", []byte("a"), []byte("p"), &err), "bob"},
+		{"all cities", tbl.Range("city", nil, nil, &err), "bob,ann,cid"},
+	} {
+		if got := keysOf(t, c.seq, &err); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	// Moving ann drops her old index entries.
+	if err := tbl.Put(wo, []byte("ann"), []byte("oslo,ops")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if got := keysOf(t, tbl.Lookup("city", []byte("paris"), &err), &err); got != "cid" {
+		t.Errorf("paris after the move: got %q", got)
+	}
+	if got := keysOf(t, tbl.Lookup("tag", []byte("dev"), &err), &err); got != "bob" {
+		t.Errorf("dev after the move: got %q", got)
+	}
+	if err := tbl.Delete(wo, []byte("bob")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := keysOf(t, tbl.Lookup("city", []byte("oslo"), &err), &err); got != "ann" {
+		t.Errorf("oslo after the delete: got %q", got)
+	}
+
+	for range tbl.Lookup("nope", nil, &err) {
+	}
+	if !errors.Is(err, ErrUnknownIndex) {
+		t.Errorf("querying an unknown index returned %v", err)
+	}
+}
+
+func TestTableConcurrentUpdates(t *testing.T) {
+	tbl, _, _, wo := openTable(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := fmt.Sprintf("city%d,tag%d", i%3, i)
+			if err := tbl.Put(wo, []byte("same"), []byte(v)); err != nil {
+				t.Errorf("Put failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Whichever Put came last, the row has exactly one entry per index.
+	var err error
+	n := 0
+	for range tbl.Range("city", nil, nil, &err) {
+		n++
+	}
+	for range tbl.Range("tag", nil, nil, &err) {
+		n++
+	}
+	if err != nil || n != 2 {
+		t.Errorf("found %d index entries, %v; want 2", n, err)
+	}
+}
+
+func TestTableNilErrorPointer(t *testing.T) {
+	table, _, _, _ := openTable(t)
+	for name, query := range map[string]func(){
+		"Lookup": func() { table.Lookup("city", []byte("a"), nil) },
+		"Range":  func() { table.Range("city", nil, nil, nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s with a nil error pointer did not panic", name)
+				}
+			}()
+			query()
+		}()
+	}
+}
@@ -0,0 +1,17 @@
+// Package keyrange computes the bounds of ranges of keys for rocksgo and
+// its subpackages, which cannot share exported code without an import
+// cycle.
+package keyrange
+
+// PrefixEnd returns the first key after every key starting with prefix, or nil
+// if there is none, as when prefix is empty or all 0xff bytes.
+func PrefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] != 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// Package scanerr checks the error pointers through which the scans of
+// rocksgo and its subpackages report their outcome.
+package scanerr
+
+// Check panics if errp is nil. The scans call it when created, so that a
+// missing error pointer is caught where the scan is written, rather than an
+// I/O error met during the scan being lost or turned into a panic.
+func Check(errp *error) {
+	if errp == nil {
+		panic("rocksgo: scan created with a nil error pointer")
+	}
+}
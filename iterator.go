@@ -7,15 +7,10 @@ package rocksgo
 import "C"
 
 import (
+	"context"
 	"unsafe"
 )
 
-type IteratorError string
-
-func (e IteratorError) Error() string {
-	return string(e)
-}
-
 // Iterator is a read-only iterator through a rocksdb database. It provides a
 // way to seek to specific keys and iterate through the keyspace from that
 // point, as well as access the values of those keys.
@@ -23,7 +18,9 @@ func (e IteratorError) Error() string {
 // Care must be taken when using an Iterator. If the method Valid returns
 // false, calls to Key, Value, Next, and Prev will result in panics. However,
 // Seek, SeekToFirst, SeekToLast, GetError, Valid, and Close will still be
-// safe to call.
+// safe to call. Once the Iterator is closed, or if it was created from a
+// closed DB, every method is safe to call: Key and Value return nil, the
+// moves do nothing, and GetError returns ErrClosed.
 //
 // GetError will only return an error in the event of a rocksdb error. It will
 // return a nil on iterators that are simply invalid. Given that behavior,
@@ -44,21 +41,37 @@ func (e IteratorError) Error() string {
 // 	}
 //
 // To prevent memory leaks, an Iterator must have Close called on it when it
-// is no longer needed by the program.
+// is no longer needed by the program. The DB it came from will not finish
+// closing until it is.
 type Iterator struct {
 	Iter *C.rocksdb_iterator_t
+
+	db   *DB
+	ctx  context.Context
+	err  error
+	leak *leakRecord
 }
 
 // Valid returns false only when an Iterator has iterated past either the
 // first or the last key in the database.
+//
+// It also returns false once the Iterator has been closed, if it was
+// created from a closed DB, or once the Context it was created with is done.
 func (it *Iterator) Valid() bool {
+	if it.Iter == nil || it.err != nil {
+		return false
+	}
 	return ucharToBool(C.rocksdb_iter_valid(it.Iter))
 }
 
 // Key returns a copy the key in the database the iterator currently holds.
 //
-// If Valid returns false, this method will panic.
+// If Valid returns false, this method will panic, unless the Iterator is
+// closed, in which case it returns nil.
 func (it *Iterator) Key() []byte {
+	if it.Iter == nil {
+		return nil
+	}
 	var klen C.size_t
 	kdata := C.rocksdb_iter_key(it.Iter, &klen)
 	if kdata == nil {
@@ -71,11 +84,29 @@ func (it *Iterator) Key() []byte {
 	return C.GoBytes(unsafe.Pointer(kdata), C.int(klen))
 }
 
+// rawKey returns the key the iterator currently holds without copying it.
+// It is only valid until the iterator is moved or closed.
+func (it *Iterator) rawKey() []byte {
+	if it.Iter == nil {
+		return nil
+	}
+	var klen C.size_t
+	kdata := C.rocksdb_iter_key(it.Iter, &klen)
+	if kdata == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(kdata)), int(klen))
+}
+
 // Value returns a copy of the value in the database the iterator currently
 // holds.
 //
-// If Valid returns false, this method will panic.
+// If Valid returns false, this method will panic, unless the Iterator is
+// closed, in which case it returns nil.
 func (it *Iterator) Value() []byte {
+	if it.Iter == nil {
+		return nil
+	}
 	var vlen C.size_t
 	vdata := C.rocksdb_iter_value(it.Iter, &vlen)
 	if vdata == nil {
@@ -91,16 +122,24 @@ func (it *Iterator) Value() []byte {
 // Next moves the iterator to the next sequential key in the database, as
 // defined by the Comparator in the ReadOptions used to create this Iterator.
 //
-// If Valid returns false, this method will panic.
+// If Valid returns false, this method will panic, unless the Iterator is
+// closed, in which case it does nothing.
 func (it *Iterator) Next() {
+	if !it.checkContext() {
+		return
+	}
 	C.rocksdb_iter_next(it.Iter)
 }
 
 // Prev moves the iterator to the previous sequential key in the database, as
 // defined by the Comparator in the ReadOptions used to create this Iterator.
 //
-// If Valid returns false, this method will panic.
+// If Valid returns false, this method will panic, unless the Iterator is
+// closed, in which case it does nothing.
 func (it *Iterator) Prev() {
+	if !it.checkContext() {
+		return
+	}
 	C.rocksdb_iter_prev(it.Iter)
 }
 
@@ -109,6 +148,9 @@ func (it *Iterator) Prev() {
 //
 // This method is safe to call when Valid returns false.
 func (it *Iterator) SeekToFirst() {
+	if !it.checkContext() {
+		return
+	}
 	C.rocksdb_iter_seek_to_first(it.Iter)
 }
 
@@ -117,6 +159,9 @@ func (it *Iterator) SeekToFirst() {
 //
 // This method is safe to call when Valid returns false.
 func (it *Iterator) SeekToLast() {
+	if !it.checkContext() {
+		return
+	}
 	C.rocksdb_iter_seek_to_last(it.Iter)
 }
 
@@ -126,26 +171,86 @@ func (it *Iterator) SeekToLast() {
 //
 // This method is safe to call when Valid returns false.
 func (it *Iterator) Seek(key []byte) {
-	C.rocksdb_iter_seek(it.Iter, (*C.char)(unsafe.Pointer(&key[0])), C.size_t(len(key)))
+	if !it.checkContext() {
+		return
+	}
+	C.rocksdb_iter_seek(it.Iter, byteSliceToChar(key), C.size_t(len(key)))
 }
 
-// GetError returns an IteratorError from rocksdb if it had one during
-// iteration.
+// SeekForPrev moves the iterator the position of the key given or, if the
+// key doesn't exist, the previous key that does exist in the database. If
+// the key doesn't exist, and there is no previous key, the Iterator becomes
+// invalid.
+//
+// This method is safe to call when Valid returns false.
+func (it *Iterator) SeekForPrev(key []byte) {
+	if !it.checkContext() {
+		return
+	}
+	C.rocksdb_iter_seek_for_prev(it.Iter, byteSliceToChar(key), C.size_t(len(key)))
+}
+
+// Refresh updates the Iterator to see the database as it is now, rather
+// than as it was when the Iterator was created, without recreating it. The
+// Iterator is left invalid, and must be positioned again with a Seek.
+//
+// An Iterator reading from a Snapshot cannot be refreshed.
+func (it *Iterator) Refresh() error {
+	if it.Iter == nil {
+		return it.err
+	}
+	var errStr *C.char
+	C.rocksdb_iter_refresh(it.Iter, &errStr)
+	return statusError(errStr)
+}
+
+// GetError returns an *Error from rocksdb if it had one during iteration.
 //
 // This method is safe to call when Valid returns false.
 func (it *Iterator) GetError() error {
+	if it.Iter == nil || it.err != nil {
+		return it.err
+	}
 	var errStr *C.char
 	C.rocksdb_iter_get_error(it.Iter, &errStr)
-	if errStr != nil {
-		gs := C.GoString(errStr)
-		C.rocksdb_free(unsafe.Pointer(errStr))
-		return IteratorError(gs)
+	err := statusError(errStr)
+	if it.ctx != nil {
+		err = contextError(it.ctx, err)
+	}
+	return err
+}
+
+// checkContext reports whether the Iterator may take another step, which it
+// may not once it is closed, or once the Context it was created with is
+// done.
+func (it *Iterator) checkContext() bool {
+	if it.err != nil {
+		return false
 	}
-	return nil
+	if it.Iter == nil {
+		it.err = ErrClosed
+		return false
+	}
+	if it.ctx != nil {
+		it.err = it.ctx.Err()
+	}
+	return it.err == nil
 }
 
 // Close deallocates the given Iterator, freeing the underlying C struct.
+//
+// Once closed, the Iterator is invalid and its GetError returns ErrClosed.
+// Closing an Iterator more than once is a no-op.
 func (it *Iterator) Close() {
+	if it.Iter == nil {
+		return
+	}
 	C.rocksdb_iter_destroy(it.Iter)
 	it.Iter = nil
+	it.err = ErrClosed
+	it.leak.untrack()
+	if it.db != nil {
+		it.db.release()
+		it.db = nil
+	}
 }
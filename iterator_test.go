@@ -0,0 +1,142 @@
+package rocksgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSeekForPrev(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	for _, k := range []string{"b", "d"} {
+		if err := db.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	it := db.NewIterator(ro)
+	defer it.Close()
+	for _, c := range []struct{ seek, want string }{
+		{"b", "b"}, {"c", "b"}, {"z", "d"}, {"a", ""},
+	} {
+		it.SeekForPrev([]byte(c.seek))
+		got := ""
+		if it.Valid() {
+			got = string(it.Key())
+		}
+		if got != c.want {
+			t.Errorf("SeekForPrev(%q) landed on %q, want %q", c.seek, got, c.want)
+		}
+	}
+}
+
+func TestIteratorRefresh(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	it := db.NewIterator(ro)
+	defer it.Close()
+	if err := db.Put(wo, []byte("a"), []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	it.SeekToFirst()
+	if it.Valid() {
+		t.Errorf("an Iterator should not see writes made after its creation")
+	}
+	if err := it.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	it.SeekToFirst()
+	if !it.Valid() || string(it.Key()) != "a" {
+		t.Errorf("a refreshed Iterator should see the key written")
+	}
+}
+
+func TestTail(t *testing.T) {
+	db, _, wo := openBinaryKeysDb(t)
+	put := func(i int) {
+		k := []byte(fmt.Sprintf("log%04d", i))
+		if err := db.Put(wo, k, k); err != nil {
+			t.Errorf("Put failed: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		put(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	seen := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- db.Tail(ctx, []byte("log"), time.Millisecond, func(k, v []byte) error {
+			seen <- string(k)
+			if string(k) == "log0005" {
+				return errors.New("stop")
+			}
+			return nil
+		})
+	}()
+	next := func() string {
+		select {
+		case k := <-seen:
+			return k
+		case err := <-done:
+			t.Fatalf("Tail returned early: %v", err)
+		}
+		return ""
+	}
+	for i := 0; i < 3; i++ {
+		if k := next(); k != fmt.Sprintf("log%04d", i) {
+			t.Errorf("tailed %q first, want log%04d", k, i)
+		}
+	}
+	for i := 3; i < 6; i++ {
+		put(i)
+		if k := next(); k != fmt.Sprintf("log%04d", i) {
+			t.Errorf("tailed %q, want log%04d", k, i)
+		}
+	}
+	if err := <-done; err == nil || err.Error() != "stop" {
+		t.Errorf("Tail should pass on the error of fn, got %v", err)
+	}
+}
+
+func TestIteratorClosed(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	if err := db.Put(wo, []byte("a"), []byte("1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	check := func(name string, it *Iterator) {
+		t.Helper()
+		it.SeekToFirst()
+		it.SeekToLast()
+		it.Seek([]byte("a"))
+		it.SeekForPrev([]byte("a"))
+		it.Next()
+		it.Prev()
+		if it.Valid() {
+			t.Errorf("%s: Valid returned true", name)
+		}
+		if k, v := it.Key(), it.Value(); k != nil || v != nil {
+			t.Errorf("%s: Key and Value = %q, %q, want nil", name, k, v)
+		}
+		if err := it.GetError(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: GetError = %v, want ErrClosed", name, err)
+		}
+		if err := it.Refresh(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: Refresh = %v, want ErrClosed", name, err)
+		}
+		it.Close()
+	}
+
+	it := db.NewIterator(ro)
+	it.SeekToFirst()
+	if !it.Valid() {
+		t.Fatalf("the Iterator should hold the key written")
+	}
+	it.Close()
+	check("closed Iterator", it)
+
+	db.Close()
+	check("Iterator of a closed DB", db.NewIterator(ro))
+}
@@ -0,0 +1,240 @@
+// Package keys encodes tuples of values into byte strings whose bytewise
+// order is the order of the tuples, for use as composite keys with the
+// default rocksdb comparator.
+//
+// A tuple such as (tenant, timestamp, id) is encoded with Encode:
+//
+//	k, err := keys.Encode("acme", time.Now(), uint64(42))
+//
+// Tuples compare element by element, a tuple sorting before any longer tuple
+// it is a prefix of. Elements of the same kind compare by value: strings and
+// byte slices bytewise, integers, floats and timestamps numerically, and
+// false before true. Elements of different kinds compare by kind, in the
+// order nil, []byte, string, int64, uint64, float64, bool, time.Time; in
+// particular, signed and unsigned integers are not interleaved.
+//
+// Decode turns an encoded key back into its elements, and PrefixRange
+// returns the Range of the keys extending a tuple.
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ananclub/rocksgo"
+	"github.com/ananclub/rocksgo/internal/keyrange"
+)
+
+// The tags starting the encoding of each kind of element, in the order the
+// kinds sort in.
+const (
+	tagNil    = 0x00
+	tagBytes  = 0x01
+	tagString = 0x02
+	tagInt    = 0x14
+	tagUint   = 0x15
+	tagFloat  = 0x21
+	tagFalse  = 0x26
+	tagTrue   = 0x27
+	tagTime   = 0x33
+)
+
+// Append appends the encoding of the tuple elems to dst and returns the
+// result. The elements may be nil, []byte, string, any signed or unsigned
+// integer type, float32, float64, bool or time.Time; any other type is an
+// error.
+//
+// Signed integers are encoded as int64, unsigned ones as uint64 and float32
+// as float64. A time.Time is encoded as its nanoseconds since the Unix epoch,
+// and so must fall between the years 1678 and 2262.
+func Append(dst []byte, elems ...any) ([]byte, error) {
+	for _, e := range elems {
+		switch v := e.(type) {
+		case nil:
+			dst = append(dst, tagNil)
+		case []byte:
+			dst = appendEscaped(append(dst, tagBytes), v)
+		case string:
+			dst = appendEscaped(append(dst, tagString), v)
+		case int:
+			dst = appendInt(dst, int64(v))
+		case int8:
+			dst = appendInt(dst, int64(v))
+		case int16:
+			dst = appendInt(dst, int64(v))
+		case int32:
+			dst = appendInt(dst, int64(v))
+		case int64:
+			dst = appendInt(dst, v)
+		case uint:
+			dst = appendUint(dst, uint64(v))
+		case uint8:
+			dst = appendUint(dst, uint64(v))
+		case uint16:
+			dst = appendUint(dst, uint64(v))
+		case uint32:
+			dst = appendUint(dst, uint64(v))
+		case uint64:
+			dst = appendUint(dst, v)
+		case float32:
+			dst = appendFloat(dst, float64(v))
+		case float64:
+			dst = appendFloat(dst, v)
+		case bool:
+			if v {
+				dst = append(dst, tagTrue)
+			} else {
+				dst = append(dst, tagFalse)
+			}
+		case time.Time:
+			dst = binary.BigEndian.AppendUint64(append(dst, tagTime), uint64(v.UnixNano())^1<<63)
+		default:
+			return dst, fmt.Errorf("keys: cannot encode %T", e)
+		}
+	}
+	return dst, nil
+}
+
+// Encode returns the encoding of the tuple elems, as described for Append.
+func Encode(elems ...any) ([]byte, error) {
+	return Append(nil, elems...)
+}
+
+// A 0x00 byte ends a string or byte slice, so 0x00 bytes within one are
+// escaped as 0x00 0xff. As 0xff sorts after every tag, a string still sorts
+// after its prefixes followed by more elements.
+func appendEscaped[T string | []byte](dst []byte, s T) []byte {
+	for i := 0; i < len(s); i++ {
+		dst = append(dst, s[i])
+		if s[i] == 0x00 {
+			dst = append(dst, 0xff)
+		}
+	}
+	return append(dst, 0x00)
+}
+
+// Flipping the sign bit makes negative numbers sort before positive ones.
+func appendInt(dst []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tagInt), uint64(v)^1<<63)
+}
+
+func appendUint(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tagUint), v)
+}
+
+// Flipping the sign bit of positive floats, and every bit of negative ones,
+// makes their IEEE 754 representations sort numerically.
+func appendFloat(dst []byte, v float64) []byte {
+	u := math.Float64bits(v)
+	if u&(1<<63) != 0 {
+		u = ^u
+	} else {
+		u ^= 1 << 63
+	}
+	return binary.BigEndian.AppendUint64(append(dst, tagFloat), u)
+}
+
+var errTruncated = errors.New("keys: truncated key")
+
+// Decode returns the elements of the tuple encoded in b. Integers are
+// returned as int64 or uint64, floats as float64, and timestamps as a
+// time.Time in UTC. The []byte elements are copies.
+func Decode(b []byte) ([]any, error) {
+	var elems []any
+	for len(b) > 0 {
+		tag := b[0]
+		b = b[1:]
+		switch tag {
+		case tagNil:
+			elems = append(elems, nil)
+		case tagBytes, tagString:
+			s, n, err := unescape(b)
+			if err != nil {
+				return nil, err
+			}
+			b = b[n:]
+			if tag == tagBytes {
+				elems = append(elems, s)
+			} else {
+				elems = append(elems, string(s))
+			}
+		case tagInt, tagUint, tagFloat, tagTime:
+			if len(b) < 8 {
+				return nil, errTruncated
+			}
+			u := binary.BigEndian.Uint64(b)
+			b = b[8:]
+			switch tag {
+			case tagInt:
+				elems = append(elems, int64(u^1<<63))
+			case tagUint:
+				elems = append(elems, u)
+			case tagFloat:
+				if u&(1<<63) != 0 {
+					u ^= 1 << 63
+				} else {
+					u = ^u
+				}
+				elems = append(elems, math.Float64frombits(u))
+			case tagTime:
+				elems = append(elems, time.Unix(0, int64(u^1<<63)).UTC())
+			}
+		case tagFalse:
+			elems = append(elems, false)
+		case tagTrue:
+			elems = append(elems, true)
+		default:
+			return nil, fmt.Errorf("keys: unknown tag 0x%02x", tag)
+		}
+	}
+	return elems, nil
+}
+
+// unescape decodes an escaped string at the start of b, returning it along
+// with the number of bytes it took, terminator included.
+func unescape(b []byte) ([]byte, int, error) {
+	s := []byte{}
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0x00 {
+			s = append(s, b[i])
+			continue
+		}
+		if i+1 < len(b) && b[i+1] == 0xff {
+			s = append(s, 0x00)
+			i++
+			continue
+		}
+		return s, i + 1, nil
+	}
+	return nil, 0, errTruncated
+}
+
+// PrefixRange returns the Range of the keys encoding the tuple elems or any
+// tuple extending it.
+func PrefixRange(elems ...any) (rocksgo.Range, error) {
+	start, err := Encode(elems...)
+	if err != nil {
+		return rocksgo.Range{}, err
+	}
+	// The keys extending the tuple continue with the tag of an element,
+	// which is never 0xff. The encoding of the tuple is also a prefix of
+	// those of some tuples not extending it, whose last string goes on
+	// with an escaped 0x00, that is 0x00 0xff; they are left out by ending
+	// the Range at 0xff rather than at PrefixEnd(start).
+	limit := append(start[:len(start):len(start)], 0xff)
+	return rocksgo.Range{Start: start, Limit: limit}, nil
+}
+
+// BytesPrefixRange returns the Range of the keys starting with prefix.
+func BytesPrefixRange(prefix []byte) rocksgo.Range {
+	return rocksgo.Range{Start: prefix, Limit: PrefixEnd(prefix)}
+}
+
+// PrefixEnd returns the first key after every key starting with prefix, or
+// nil if there is none, as when prefix is empty or all 0xff bytes.
+func PrefixEnd(prefix []byte) []byte {
+	return keyrange.PrefixEnd(prefix)
+}
@@ -0,0 +1,206 @@
+package keys
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// tuple generates short tuples of every kind of element, drawing from
+// small pools so that equal elements and shared prefixes come up often.
+type tuple []any
+
+func (tuple) Generate(r *rand.Rand, size int) reflect.Value {
+	strs := []string{"", "a", "a\x00", "a\x00\xff", "ab", "b", "\xff"}
+	ints := []int64{math.MinInt64, -2, -1, 0, 1, 2, math.MaxInt64}
+	floats := []float64{math.Inf(-1), -1.5, -0.25, 0, 0.25, 1.5, math.Inf(1)}
+	t := make(tuple, r.Intn(4))
+	for i := range t {
+		switch r.Intn(8) {
+		case 0:
+			t[i] = nil
+		case 1:
+			t[i] = []byte(strs[r.Intn(len(strs))])
+		case 2:
+			t[i] = strs[r.Intn(len(strs))]
+		case 3:
+			t[i] = ints[r.Intn(len(ints))]
+		case 4:
+			t[i] = uint64(ints[r.Intn(len(ints))])
+		case 5:
+			t[i] = floats[r.Intn(len(floats))]
+		case 6:
+			t[i] = r.Intn(2) == 0
+		case 7:
+			t[i] = time.Unix(0, ints[r.Intn(3)+2]*1e9).UTC()
+		}
+	}
+	return reflect.ValueOf(t)
+}
+
+var kindOrder = map[reflect.Type]int{
+	nil:                         0,
+	reflect.TypeOf([]byte{}):    1,
+	reflect.TypeOf(""):          2,
+	reflect.TypeOf(int64(0)):    3,
+	reflect.TypeOf(uint64(0)):   4,
+	reflect.TypeOf(float64(0)):  5,
+	reflect.TypeOf(false):       6,
+	reflect.TypeOf(time.Time{}): 7,
+}
+
+// compare is the tuple order the encoding must preserve.
+func compare(a, b tuple) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ka, kb := kindOrder[reflect.TypeOf(a[i])], kindOrder[reflect.TypeOf(b[i])]
+		if ka != kb {
+			return ka - kb
+		}
+		var c int
+		switch x := a[i].(type) {
+		case []byte:
+			c = bytes.Compare(x, b[i].([]byte))
+		case string:
+			c = bytes.Compare([]byte(x), []byte(b[i].(string)))
+		case int64:
+			c = cmp(x, b[i].(int64))
+		case uint64:
+			c = cmp(x, b[i].(uint64))
+		case float64:
+			c = cmp(x, b[i].(float64))
+		case bool:
+			c = cmp(btoi(x), btoi(b[i].(bool)))
+		case time.Time:
+			c = x.Compare(b[i].(time.Time))
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func cmp[T int64 | uint64 | float64 | int](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sign(n int) int {
+	return cmp(n, 0)
+}
+
+func TestOrderPreserved(t *testing.T) {
+	f := func(a, b tuple) bool {
+		ea, err := Encode(a...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eb, err := Encode(b...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sign(bytes.Compare(ea, eb)) != sign(compare(a, b)) {
+			t.Errorf("%v and %v encode to %x and %x, out of order", a, b, ea, eb)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	f := func(a tuple) bool {
+		e, err := Encode(a...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := Decode(e)
+		if err != nil {
+			t.Errorf("decoding %x failed: %v", e, err)
+			return false
+		}
+		if len(d) != len(a) || (len(a) > 0 && !reflect.DeepEqual([]any(a), d)) {
+			t.Errorf("%v decoded as %v", a, d)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEncodeConversions(t *testing.T) {
+	e, err := Encode(int8(-3), uint16(7), float32(0.5), 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Decode(e)
+	want := []any{int64(-3), uint64(7), float64(0.5), int64(9)}
+	if err != nil || !reflect.DeepEqual(d, want) {
+		t.Errorf("decoded %v, %v; want %v", d, err, want)
+	}
+	if _, err := Encode(struct{}{}); err == nil {
+		t.Errorf("encoding a struct should fail")
+	}
+	for _, bad := range [][]byte{{tagString, 'a'}, {tagInt, 1, 2}, {0x99}} {
+		if _, err := Decode(bad); err == nil {
+			t.Errorf("decoding %x should fail", bad)
+		}
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	f := func(prefix, other tuple) bool {
+		r, err := PrefixRange(prefix...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, _ := Encode(other...)
+		in := bytes.Compare(e, r.Start) >= 0 && bytes.Compare(e, r.Limit) < 0
+		extends := len(other) >= len(prefix) && compare(other[:len(prefix)], prefix) == 0
+		if in != extends {
+			t.Errorf("%v in the range of %v: %v, want %v", other, prefix, in, extends)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	for _, c := range []struct{ in, want []byte }{
+		{[]byte("ab"), []byte("ac")},
+		{[]byte{'a', 0xff, 0xff}, []byte("b")},
+		{[]byte{0xff}, nil},
+		{nil, nil},
+	} {
+		if got := PrefixEnd(c.in); !bytes.Equal(got, c.want) || (got == nil) != (c.want == nil) {
+			t.Errorf("PrefixEnd(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	r := BytesPrefixRange([]byte("ab"))
+	if string(r.Start) != "ab" || string(r.Limit) != "ac" {
+		t.Errorf("BytesPrefixRange(ab) = %q", r)
+	}
+}
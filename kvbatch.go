@@ -0,0 +1,148 @@
+package rocksgo
+
+/*
+#cgo LDFLAGS: -lrocksdb
+#include <string.h>
+#include "rocksdb/c.h"
+
+// rocksgo_iter_next_batch copies up to max entries from it into buf, keys
+// and values back to back, recording their lengths in lens, and advances it
+// past them. It stops before the first entry that does not fit in cap
+// bytes; if that is the very first one, its size is stored in *need.
+static int rocksgo_iter_next_batch(rocksdb_iterator_t* it, char* buf,
+                                   size_t cap, size_t* lens, int max,
+                                   size_t* need) {
+  size_t off = 0;
+  int n = 0;
+  *need = 0;
+  while (n < max && rocksdb_iter_valid(it)) {
+    size_t klen, vlen;
+    const char* k = rocksdb_iter_key(it, &klen);
+    const char* v = rocksdb_iter_value(it, &vlen);
+    if (off + klen + vlen > cap) {
+      if (n == 0) {
+        *need = klen + vlen;
+      }
+      break;
+    }
+    memcpy(buf + off, k, klen);
+    off += klen;
+    memcpy(buf + off, v, vlen);
+    off += vlen;
+    lens[2 * n] = klen;
+    lens[2 * n + 1] = vlen;
+    n++;
+    rocksdb_iter_next(it);
+  }
+  return n;
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// KVBatch holds the key-value pairs copied out of an Iterator by
+// Iterator.NextBatch, in a single buffer reused from one call to the next.
+//
+// The zero KVBatch is empty and ready to use.
+type KVBatch struct {
+	data []byte
+	lens []C.size_t
+	// ends holds, for each entry, the offsets in data where its key and
+	// its value end.
+	ends []int
+}
+
+// Len returns the number of entries in the batch.
+func (b *KVBatch) Len() int {
+	return len(b.ends) / 2
+}
+
+// Key returns the key of the i'th entry. It is a view into the batch, and
+// is overwritten by the next call to NextBatch with the batch.
+func (b *KVBatch) Key(i int) []byte {
+	start := 0
+	if i > 0 {
+		start = b.ends[2*i-1]
+	}
+	end := b.ends[2*i]
+	return b.data[start:end:end]
+}
+
+// Value returns the value of the i'th entry. It is a view into the batch,
+// and is overwritten by the next call to NextBatch with the batch.
+func (b *KVBatch) Value(i int) []byte {
+	start, end := b.ends[2*i], b.ends[2*i+1]
+	return b.data[start:end:end]
+}
+
+// Reset empties the batch, keeping its buffer for reuse.
+func (b *KVBatch) Reset() {
+	b.ends = b.ends[:0]
+}
+
+// NextBatch copies up to maxEntries key-value pairs into buf, starting with
+// the one the Iterator currently holds, and moves the Iterator past them. It
+// returns the number of pairs copied, which is zero once the Iterator is no
+// longer Valid.
+//
+// The pairs are copied in a single call into rocksdb, rather than one for
+// each of Valid, Key, Value and Next, and buf's buffer is reused. To bound
+// that buffer, the pairs copied together stop short of maxBytes of keys and
+// values, except that a single pair larger than maxBytes is still copied
+// on its own.
+//
+// A typical scan looks like:
+//
+//	var batch rocksgo.KVBatch
+//	for it.SeekToFirst(); it.NextBatch(&batch, 256, 64<<10) > 0; {
+//		for i := 0; i < batch.Len(); i++ {
+//			useKeyAndValue(batch.Key(i), batch.Value(i))
+//		}
+//	}
+//	if err := it.GetError(); err != nil {
+//		...
+//	}
+func (it *Iterator) NextBatch(buf *KVBatch, maxEntries, maxBytes int) int {
+	buf.Reset()
+	if it.Iter == nil || maxEntries <= 0 || !it.checkContext() {
+		return 0
+	}
+	if cap(buf.data) < maxBytes {
+		buf.data = make([]byte, maxBytes)
+	}
+	if cap(buf.lens) < 2*maxEntries {
+		buf.lens = make([]C.size_t, 2*maxEntries)
+	}
+	buf.data = buf.data[:cap(buf.data)]
+	buf.lens = buf.lens[:cap(buf.lens)]
+
+	var need C.size_t
+	n := it.nextBatch(buf, maxEntries, max(maxBytes, 0), &need)
+	if n == 0 && need > 0 {
+		if cap(buf.data) < int(need) {
+			buf.data = make([]byte, need)
+		}
+		n = it.nextBatch(buf, 1, int(need), &need)
+	}
+
+	off := 0
+	for _, l := range buf.lens[:2*n] {
+		off += int(l)
+		buf.ends = append(buf.ends, off)
+	}
+	return n
+}
+
+func (it *Iterator) nextBatch(buf *KVBatch, maxEntries, maxBytes int, need *C.size_t) int {
+	// buf.data holds no Go pointers, so it may be handed to C directly. It
+	// is only empty when maxBytes is zero, and then C writes nothing to it.
+	var data *C.char
+	if len(buf.data) > 0 {
+		data = (*C.char)(unsafe.Pointer(&buf.data[0]))
+	}
+	return int(C.rocksgo_iter_next_batch(it.Iter, data, C.size_t(maxBytes),
+		&buf.lens[0], C.int(maxEntries), need))
+}
@@ -0,0 +1,110 @@
+package rocksgo
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestIteratorNextBatch(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	var want [][2][]byte
+	for i := 0; i < 100; i++ {
+		k := []byte(fmt.Sprintf("key%03d", i))
+		v := bytes.Repeat([]byte{byte(i)}, i)
+		if err := db.Put(wo, k, v); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		want = append(want, [2][]byte{k, v})
+	}
+
+	for _, c := range []struct{ maxEntries, maxBytes int }{
+		{1000, 1 << 20}, {7, 1 << 20}, {1000, 64}, {1000, 0}, {3, 10},
+	} {
+		it := db.NewIterator(ro)
+		var batch KVBatch
+		var got [][2][]byte
+		for it.SeekToFirst(); it.NextBatch(&batch, c.maxEntries, c.maxBytes) > 0; {
+			if batch.Len() > c.maxEntries {
+				t.Errorf("%+v: a batch of %d entries", c, batch.Len())
+			}
+			for i := 0; i < batch.Len(); i++ {
+				got = append(got, [2][]byte{
+					bytes.Clone(batch.Key(i)), bytes.Clone(batch.Value(i)),
+				})
+			}
+		}
+		if err := it.GetError(); err != nil {
+			t.Errorf("%+v: iteration failed: %v", c, err)
+		}
+		it.Close()
+		if len(got) != len(want) {
+			t.Errorf("%+v: got %d entries, want %d", c, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if !bytes.Equal(got[i][0], want[i][0]) || !bytes.Equal(got[i][1], want[i][1]) {
+				t.Errorf("%+v: entry %d is %q, want %q", c, i, got[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func openBenchDb(b *testing.B, n int) (*DB, *ReadOptions) {
+	options := NewOptions()
+	options.SetCreateIfMissing(true)
+	ro := NewReadOptions()
+	wo := NewWriteOptions()
+	db, err := Open(b.TempDir(), options)
+	if err != nil {
+		b.Fatalf("Database could not be opened: %v", err)
+	}
+	b.Cleanup(func() {
+		db.Close()
+		options.Close()
+		ro.Close()
+		wo.Close()
+	})
+	wb := NewWriteBatch()
+	defer wb.Close()
+	v := bytes.Repeat([]byte("v"), 100)
+	for i := 0; i < n; i++ {
+		wb.Put([]byte(fmt.Sprintf("key%08d", i)), v)
+	}
+	if err := db.Write(wo, wb); err != nil {
+		b.Fatalf("Write failed: %v", err)
+	}
+	return db, ro
+}
+
+func BenchmarkIteratorLoop(b *testing.B) {
+	db, ro := openBenchDb(b, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		it := db.NewIterator(ro)
+		n := 0
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			n += len(it.Key()) + len(it.Value())
+		}
+		it.Close()
+	}
+}
+
+func BenchmarkIteratorNextBatch(b *testing.B) {
+	db, ro := openBenchDb(b, 10000)
+	var batch KVBatch
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		it := db.NewIterator(ro)
+		n := 0
+		for it.SeekToFirst(); it.NextBatch(&batch, 256, 64<<10) > 0; {
+			for j := 0; j < batch.Len(); j++ {
+				n += len(batch.Key(j)) + len(batch.Value(j))
+			}
+		}
+		it.Close()
+	}
+}
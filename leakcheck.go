@@ -0,0 +1,209 @@
+package rocksgo
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Leak detection records where every native object (Iterator, Snapshot,
+// WriteBatch, Options, ReadOptions, WriteOptions, Cache, FilterPolicy and
+// Env) was created, and reports the ones that are garbage collected without
+// being closed, as well as the Iterators and Snapshots still open when their
+// DB is closed.
+//
+// It is off by default, since capturing a stack trace for every object is
+// not free. Turn it on with SetLeakDetection, or build with the
+// rocksgo_leakcheck tag to have it on from the start:
+//
+// 	go test -tags rocksgo_leakcheck ./...
+//
+// Objects created while leak detection is off are never tracked.
+
+// LeakEvent says why a Resource is being reported as leaked.
+type LeakEvent int
+
+const (
+	// LeakCollected is reported when an object is garbage collected
+	// without its Close (or DB.ReleaseSnapshot) having been called.
+	LeakCollected = LeakEvent(0)
+	// LeakOpenAtClose is reported for every Iterator and Snapshot still
+	// open when DB.Close is called. Close waits for them.
+	LeakOpenAtClose = LeakEvent(1)
+)
+
+func (ev LeakEvent) String() string {
+	switch ev {
+	case LeakCollected:
+		return "garbage collected without being closed"
+	case LeakOpenAtClose:
+		return "still open when its DB was closed"
+	}
+	return "leaked"
+}
+
+// Resource describes a native object tracked by leak detection.
+type Resource struct {
+	// Kind is the name of the object's type, e.g. "Iterator".
+	Kind string
+	// Created is when the object was created.
+	Created time.Time
+	// Stack is the stack trace of the goroutine that created the object.
+	Stack string
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s created at %s by:\n%s",
+		r.Kind, r.Created.Format(time.RFC3339Nano), r.Stack)
+}
+
+var (
+	leakDetection atomic.Bool
+	leakReporter  atomic.Pointer[func(LeakEvent, Resource)]
+
+	leaks = struct {
+		sync.Mutex
+		open map[*leakRecord]struct{}
+	}{open: make(map[*leakRecord]struct{})}
+)
+
+func init() {
+	leakDetection.Store(leakDetectionDefault)
+}
+
+// SetLeakDetection turns leak detection on or off. It only affects objects
+// created afterwards.
+func SetLeakDetection(enabled bool) {
+	leakDetection.Store(enabled)
+}
+
+// LeakDetection reports whether leak detection is on.
+func LeakDetection() bool {
+	return leakDetection.Load()
+}
+
+// SetLeakReporter sets the function leaks are reported to. The default logs
+// them with the log package. A nil f restores the default.
+//
+// f may be called from the goroutine running finalizers, so it must not
+// block.
+func SetLeakReporter(f func(ev LeakEvent, r Resource)) {
+	if f == nil {
+		leakReporter.Store(nil)
+		return
+	}
+	leakReporter.Store(&f)
+}
+
+// OpenResources returns the tracked native objects that have not been
+// closed yet, oldest first.
+func OpenResources() []Resource {
+	return openResources(func(*leakRecord) bool { return true })
+}
+
+// OpenResources returns the Iterators and Snapshots created from the DB that
+// have not been closed or released yet, oldest first. Only objects created
+// while leak detection was on are listed.
+func (db *DB) OpenResources() []Resource {
+	return openResources(func(rec *leakRecord) bool { return rec.db == db })
+}
+
+// reportOpenResources reports the Iterators and Snapshots keeping Close
+// from finishing.
+func (db *DB) reportOpenResources() {
+	for _, r := range db.OpenResources() {
+		reportLeak(LeakOpenAtClose, r)
+	}
+}
+
+// leakRecord is kept by a tracked object, which hands it to untrack when
+// it is closed.
+type leakRecord struct {
+	kind    string
+	db      *DB
+	created time.Time
+	pcs     []uintptr
+	cleanup runtime.Cleanup
+}
+
+// trackResource starts tracking obj if leak detection is on, returning nil
+// otherwise. db is set for objects that keep a DB from closing.
+func trackResource[T any](obj *T, kind string, db *DB) *leakRecord {
+	if !leakDetection.Load() {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, trackResource and the constructor calling it.
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	rec := &leakRecord{kind: kind, db: db, created: time.Now(), pcs: pcs}
+
+	leaks.Lock()
+	leaks.open[rec] = struct{}{}
+	leaks.Unlock()
+	// The cleanup must not refer to obj, or obj would never be collected.
+	rec.cleanup = runtime.AddCleanup(obj, collected, rec)
+	return rec
+}
+
+// untrack stops tracking the object rec was returned for. It is a no-op on
+// a nil rec.
+func (rec *leakRecord) untrack() {
+	if rec == nil {
+		return
+	}
+	rec.cleanup.Stop()
+	leaks.Lock()
+	delete(leaks.open, rec)
+	leaks.Unlock()
+}
+
+func (rec *leakRecord) resource() Resource {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(rec.pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return Resource{Kind: rec.kind, Created: rec.created, Stack: sb.String()}
+}
+
+func collected(rec *leakRecord) {
+	leaks.Lock()
+	delete(leaks.open, rec)
+	leaks.Unlock()
+	reportLeak(LeakCollected, rec.resource())
+}
+
+func reportLeak(ev LeakEvent, r Resource) {
+	if f := leakReporter.Load(); f != nil {
+		(*f)(ev, r)
+		return
+	}
+	log.Printf("rocksgo: %s %s: %s", r.Kind, ev, r)
+}
+
+func openResources(match func(*leakRecord) bool) []Resource {
+	leaks.Lock()
+	var recs []*leakRecord
+	for rec := range leaks.open {
+		if match(rec) {
+			recs = append(recs, rec)
+		}
+	}
+	leaks.Unlock()
+
+	rs := make([]Resource, 0, len(recs))
+	for _, rec := range recs {
+		rs = append(rs, rec.resource())
+	}
+	slices.SortFunc(rs, func(a, b Resource) int { return a.Created.Compare(b.Created) })
+	return rs
+}
@@ -0,0 +1,5 @@
+//go:build rocksgo_leakcheck
+
+package rocksgo
+
+const leakDetectionDefault = true
@@ -0,0 +1,111 @@
+package rocksgo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type leakReport struct {
+	ev LeakEvent
+	r  Resource
+}
+
+// withLeakDetection turns leak detection on for the test, and returns the
+// channel the leaks are reported on. The reporter may run on the finalizer
+// goroutine, even after the test is over, so it only passes the reports on,
+// for the test to check with checkLeakReport; those overflowing the channel
+// are dropped rather than block.
+func withLeakDetection(t *testing.T) chan leakReport {
+	reports := make(chan leakReport, 16)
+	old := LeakDetection()
+	SetLeakDetection(true)
+	SetLeakReporter(func(ev LeakEvent, r Resource) {
+		select {
+		case reports <- leakReport{ev, r}:
+		default:
+		}
+	})
+	t.Cleanup(func() {
+		SetLeakDetection(old)
+		SetLeakReporter(nil)
+	})
+	return reports
+}
+
+// checkLeakReport checks that the stack of a reported resource points at
+// the test creating it.
+func checkLeakReport(t *testing.T, rep leakReport) {
+	t.Helper()
+	if !strings.Contains(rep.r.Stack, "rocksgo.Test") {
+		t.Errorf("%s stack should point at the test creating it:\n%s", rep.r.Kind, rep.r.Stack)
+	}
+}
+
+func TestLeakDetectionCollected(t *testing.T) {
+	reports := withLeakDetection(t)
+
+	closed := NewWriteBatch()
+	closed.Close()
+	func() {
+		NewWriteBatch()
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case rep := <-reports:
+			checkLeakReport(t, rep)
+			if rep.ev != LeakCollected || rep.r.Kind != "WriteBatch" {
+				t.Errorf("expected a collected WriteBatch, got %v %v", rep.r.Kind, rep.ev)
+			}
+			if rs := OpenResources(); len(rs) != 0 {
+				t.Errorf("no resources should be open after the leak was reported, got %v", rs)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("the unclosed WriteBatch was never reported")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestLeakDetectionOpenAtClose(t *testing.T) {
+	reports := withLeakDetection(t)
+
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	options.SetCreateIfMissing(true)
+	defer options.Close()
+	ro := NewReadOptions()
+	defer ro.Close()
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+
+	it := db.NewIterator(ro)
+	snap := db.NewSnapshot()
+	rs := db.OpenResources()
+	if len(rs) != 2 || rs[0].Kind != "Iterator" || rs[1].Kind != "Snapshot" {
+		t.Fatalf("expected an open Iterator and Snapshot, got %v", rs)
+	}
+	if err := db.CloseTimeout(time.Millisecond); err != ErrCloseTimeout {
+		t.Errorf("CloseTimeout should wait for the Iterator, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		rep := <-reports
+		checkLeakReport(t, rep)
+		if rep.ev != LeakOpenAtClose {
+			t.Errorf("expected a LeakOpenAtClose event, got %v", rep.ev)
+		}
+	}
+	it.Close()
+	db.ReleaseSnapshot(snap)
+	if rs := db.OpenResources(); len(rs) != 0 {
+		t.Errorf("closed resources should not be listed, got %v", rs)
+	}
+}
@@ -2,6 +2,7 @@ package rocksgo
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -46,6 +47,8 @@ func TestC(t *testing.T) {
 	db, err := Open(dbname, options)
 	if err == nil {
 		t.Errorf("Open on missing db should have failed")
+	} else if !IsInvalidArgument(err) {
+		t.Errorf("Open on missing db should fail with InvalidArgument: %v", err)
 	}
 
 	options.SetCreateIfMissing(true)
@@ -148,6 +151,21 @@ func TestC(t *testing.T) {
 	if prop == "" {
 		t.Errorf("property rocksdb.stats should have a value")
 	}
+	if _, ok := db.GetIntProperty("nosuchprop"); ok {
+		t.Errorf("int property nosuchprop should not have a value")
+	}
+	if nkeys, ok := db.GetIntProperty(PropertyEstimateNumKeys); !ok || nkeys == 0 {
+		t.Errorf("property %s should be positive, got %d (%v)", PropertyEstimateNumKeys, nkeys, ok)
+	}
+	if _, ok := db.GetIntProperty(PropertyNumFilesAtLevel(0)); !ok {
+		t.Errorf("property %s should have a value", PropertyNumFilesAtLevel(0))
+	}
+	if _, ok := db.GetMapProperty(PropertyCFStats); !ok {
+		t.Errorf("map property %s should have a value", PropertyCFStats)
+	}
+	if props := db.Properties(); len(props.NumFilesAtLevel) == 0 {
+		t.Errorf("Properties should report files per level")
+	}
 
 	// snapshot
 	snap := db.NewSnapshot()
@@ -267,6 +285,40 @@ func TestNilSlicesInDb(t *testing.T) {
 
 }
 
+func TestMultiGet(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	defer options.Close()
+	options.SetCreateIfMissing(true)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	defer db.Close()
+	ro := NewReadOptions()
+	defer ro.Close()
+	wo := NewWriteOptions()
+	defer wo.Close()
+	db.Put(wo, []byte("a"), []byte("1"))
+	db.Put(wo, []byte("b"), []byte{})
+	db.Put(wo, nil, []byte("empty key"))
+
+	values, err := db.MultiGet(ro, [][]byte{[]byte("a"), []byte("missing"), []byte("b"), nil})
+	if err != nil {
+		t.Fatalf("MultiGet failed: %v", err)
+	}
+	want := [][]byte{[]byte("1"), nil, {}, []byte("empty key")}
+	for i := range want {
+		if (values[i] == nil) != (want[i] == nil) || !bytes.Equal(values[i], want[i]) {
+			t.Errorf("value %d = %#v, want %#v", i, values[i], want[i])
+		}
+	}
+	if values, err := db.MultiGet(ro, nil); err != nil || len(values) != 0 {
+		t.Errorf("MultiGet of no keys = %v, %v", values, err)
+	}
+}
+
 func TestIterationValidityLimits(t *testing.T) {
 	dbname := tempDir(t)
 	defer deleteDBDirectory(t, dbname)
@@ -314,6 +366,97 @@ func TestIterationValidityLimits(t *testing.T) {
 	}
 }
 
+func TestCloseLifecycle(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	options.SetCreateIfMissing(true)
+	defer options.Close()
+	ro := NewReadOptions()
+	defer ro.Close()
+	wo := NewWriteOptions()
+	defer wo.Close()
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	if err := db.Put(wo, []byte("foo"), []byte("bar")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	it := db.NewIterator(ro)
+	snap := db.NewSnapshot()
+	if err := db.CloseTimeout(10 * time.Millisecond); err != ErrCloseTimeout {
+		t.Errorf("CloseTimeout with an open Iterator should time out, got %v", err)
+	}
+	if err := db.Put(wo, []byte("foo"), []byte("baz")); err != ErrClosed {
+		t.Errorf("Put after Close should return ErrClosed, got %v", err)
+	}
+	if _, err := db.Get(ro, []byte("foo")); err != ErrClosed {
+		t.Errorf("Get after Close should return ErrClosed, got %v", err)
+	}
+	if err := db.Close(); err != ErrClosed {
+		t.Errorf("second Close should return ErrClosed, got %v", err)
+	}
+	closedIt := db.NewIterator(ro)
+	closedIt.SeekToFirst()
+	if closedIt.Valid() || closedIt.GetError() != ErrClosed {
+		t.Errorf("Iterator from a closed DB should be invalid with ErrClosed, got %v", closedIt.GetError())
+	}
+	closedIt.Close()
+
+	// The Iterator opened before Close keeps working until it is closed.
+	it.SeekToFirst()
+	CheckIter(t, it, []byte("foo"), []byte("bar"))
+	it.Close()
+	it.Close()
+	db.ReleaseSnapshot(snap)
+	db.ReleaseSnapshot(snap)
+	select {
+	case <-db.drained:
+	case <-time.After(5 * time.Second):
+		t.Errorf("DB did not finish closing after its Iterator and Snapshot were released")
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		code    Code
+		subCode SubCode
+		message string
+		is      error
+	}{
+		{"NotFound: ", CodeNotFound, SubCodeNone, "", ErrNotFound},
+		{"Corruption: bad block contents", CodeCorruption, SubCodeNone, "bad block contents", ErrCorruption},
+		{"IO error: No space left on device: /tmp/db/000012.sst", CodeIOError, SubCodeNoSpace, "/tmp/db/000012.sst", ErrNoSpace},
+		{"IO error: While open a file for appending: /tmp/db/LOG", CodeIOError, SubCodeNone, "While open a file for appending: /tmp/db/LOG", ErrIOError},
+		{"Resource busy: ", CodeBusy, SubCodeNone, "", ErrBusy},
+		{"Operation timed out: Timeout Acquiring Mutex", CodeTimedOut, SubCodeMutexTimeout, "", ErrTimedOut},
+		{"Operation failed. Try again.: ", CodeTryAgain, SubCodeNone, "", ErrTryAgain},
+		{"Result incomplete: ", CodeIncomplete, SubCodeNone, "", ErrIncomplete},
+		{"Shutdown in progress: ", CodeShutdownInProgress, SubCodeNone, "", ErrShutdownInProgress},
+	} {
+		err := parseError(tc.text)
+		if err.Error() != tc.text {
+			t.Errorf("%q: Error() changed the text to %q", tc.text, err.Error())
+		}
+		if err.Code != tc.code || err.SubCode != tc.subCode || err.Message != tc.message {
+			t.Errorf("%q: parsed as %v/%d/%q, want %v/%d/%q", tc.text,
+				err.Code, err.SubCode, err.Message, tc.code, tc.subCode, tc.message)
+		}
+		if !errors.Is(fmt.Errorf("wrapped: %w", err), tc.is) {
+			t.Errorf("%q: errors.Is(err, %v) should hold", tc.text, tc.is)
+		}
+	}
+	if errors.Is(parseError("IO error: disk on fire"), ErrNoSpace) {
+		t.Errorf("an IOError without SubCodeNoSpace should not match ErrNoSpace")
+	}
+	if IsCorruption(parseError("NotFound: ")) {
+		t.Errorf("a NotFound error should not be reported as corruption")
+	}
+}
+
 func CheckGet(t *testing.T, where string, db *DB, roptions *ReadOptions, key, expected []byte) {
 	getValue, err := db.Get(roptions, key)
 
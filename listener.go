@@ -0,0 +1,265 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"strconv"
+	"time"
+)
+
+// EventListener is notified by rocksdb of the background work done on a
+// database. It is added to an Options with AddEventListener.
+//
+// The methods are called synchronously from rocksdb's background threads,
+// often while the thread holds on to resources a flush or a compaction
+// needs. They should return quickly, handing any slow work to a goroutine
+// of their own, and must not call back into the database that reported the
+// event. Several of them may run at once.
+//
+// Embed NopEventListener to implement only the events of interest.
+type EventListener interface {
+	// OnFlushCompleted is called once a memtable has been flushed to an
+	// SST file.
+	OnFlushCompleted(info FlushJobInfo)
+	// OnCompactionCompleted is called once a compaction has finished,
+	// whether it succeeded or not.
+	OnCompactionCompleted(info CompactionJobInfo)
+	// OnTableFileCreated is called once an SST file has been written, or
+	// has failed to be.
+	OnTableFileCreated(info TableFileCreationInfo)
+	// OnTableFileDeleted is called once an SST file has been deleted.
+	OnTableFileDeleted(info TableFileDeletionInfo)
+	// OnStallConditionsChanged is called when writes to a column family
+	// start or stop being delayed or stopped.
+	OnStallConditionsChanged(info WriteStallInfo)
+	// OnBackgroundError is called when a background operation fails and
+	// puts the database in read-only mode.
+	OnBackgroundError(info BackgroundErrorInfo)
+}
+
+// NopEventListener implements every EventListener method by doing nothing.
+type NopEventListener struct{}
+
+func (NopEventListener) OnFlushCompleted(FlushJobInfo)            {}
+func (NopEventListener) OnCompactionCompleted(CompactionJobInfo)  {}
+func (NopEventListener) OnTableFileCreated(TableFileCreationInfo) {}
+func (NopEventListener) OnTableFileDeleted(TableFileDeletionInfo) {}
+func (NopEventListener) OnStallConditionsChanged(WriteStallInfo)  {}
+func (NopEventListener) OnBackgroundError(BackgroundErrorInfo)    {}
+
+// FlushJobInfo describes a completed flush.
+type FlushJobInfo struct {
+	JobID        int
+	ColumnFamily string
+	// FilePath is the SST file the memtable was flushed to.
+	FilePath string
+	Reason   FlushReason
+	// SmallestSeqno and LargestSeqno bound the sequence numbers of the
+	// entries flushed.
+	SmallestSeqno uint64
+	LargestSeqno  uint64
+	// NumEntries and DataSize are the number of entries in the new file,
+	// and the size in bytes of its data blocks.
+	NumEntries uint64
+	DataSize   uint64
+	// TriggeredWritesSlowdown and TriggeredWritesStop report whether the
+	// flush left enough L0 files for writes to be slowed down or stopped.
+	TriggeredWritesSlowdown bool
+	TriggeredWritesStop     bool
+}
+
+// CompactionJobInfo describes a completed compaction.
+type CompactionJobInfo struct {
+	JobID        int
+	ColumnFamily string
+	// Err is non-nil if the compaction failed.
+	Err            error
+	Reason         CompactionReason
+	BaseInputLevel int
+	OutputLevel    int
+	InputFiles     []string
+	OutputFiles    []string
+	Duration       time.Duration
+	InputBytes     uint64
+	OutputBytes    uint64
+	InputRecords   uint64
+	OutputRecords  uint64
+}
+
+// TableFileCreationInfo describes the creation of an SST file.
+type TableFileCreationInfo struct {
+	JobID        int
+	DBName       string
+	ColumnFamily string
+	FilePath     string
+	// Err is non-nil if the file could not be written.
+	Err        error
+	Reason     TableFileCreationReason
+	FileSize   uint64
+	NumEntries uint64
+}
+
+// TableFileDeletionInfo describes the deletion of an SST file.
+type TableFileDeletionInfo struct {
+	JobID    int
+	DBName   string
+	FilePath string
+	// Err is non-nil if the file could not be deleted.
+	Err error
+}
+
+// WriteStallInfo describes a change of the write stall condition of a
+// column family.
+type WriteStallInfo struct {
+	ColumnFamily string
+	Condition    WriteStallCondition
+	Previous     WriteStallCondition
+}
+
+// BackgroundErrorInfo describes a failed background operation.
+type BackgroundErrorInfo struct {
+	Reason BackgroundErrorReason
+	Err    error
+}
+
+// FlushReason is why a memtable was flushed.
+type FlushReason int
+
+const (
+	FlushReasonOthers                    = FlushReason(0)
+	FlushReasonGetLiveFiles              = FlushReason(1)
+	FlushReasonShutDown                  = FlushReason(2)
+	FlushReasonExternalFileIngestion     = FlushReason(3)
+	FlushReasonManualCompaction          = FlushReason(4)
+	FlushReasonWriteBufferManager        = FlushReason(5)
+	FlushReasonWriteBufferFull           = FlushReason(6)
+	FlushReasonTest                      = FlushReason(7)
+	FlushReasonDeleteFiles               = FlushReason(8)
+	FlushReasonAutoCompaction            = FlushReason(9)
+	FlushReasonManualFlush               = FlushReason(10)
+	FlushReasonErrorRecovery             = FlushReason(11)
+	FlushReasonErrorRecoveryRetryFlush   = FlushReason(12)
+	FlushReasonWalFull                   = FlushReason(13)
+	FlushReasonCatchUpAfterErrorRecovery = FlushReason(14)
+)
+
+var flushReasonNames = []string{
+	"Other Reasons", "Get Live Files", "Shut Down", "External File Ingestion",
+	"Manual Compaction", "Write Buffer Manager", "Write Buffer Full", "Test",
+	"Delete Files", "Auto Compaction", "Manual Flush", "Error Recovery",
+	"Error Recovery Retry Flush", "WAL Full", "Catch Up After Error Recovery",
+}
+
+func (r FlushReason) String() string {
+	return enumName(flushReasonNames, int(r), "FlushReason")
+}
+
+// CompactionReason is why a compaction was run.
+type CompactionReason int
+
+const (
+	CompactionReasonUnknown                    = CompactionReason(0)
+	CompactionReasonLevelL0FilesNum            = CompactionReason(1)
+	CompactionReasonLevelMaxLevelSize          = CompactionReason(2)
+	CompactionReasonUniversalSizeAmplification = CompactionReason(3)
+	CompactionReasonUniversalSizeRatio         = CompactionReason(4)
+	CompactionReasonUniversalSortedRunNum      = CompactionReason(5)
+	CompactionReasonFIFOMaxSize                = CompactionReason(6)
+	CompactionReasonFIFOReduceNumFiles         = CompactionReason(7)
+	CompactionReasonFIFOTtl                    = CompactionReason(8)
+	CompactionReasonManualCompaction           = CompactionReason(9)
+	CompactionReasonFilesMarkedForCompaction   = CompactionReason(10)
+	CompactionReasonBottommostFiles            = CompactionReason(11)
+	CompactionReasonTtl                        = CompactionReason(12)
+	CompactionReasonFlush                      = CompactionReason(13)
+	CompactionReasonExternalSstIngestion       = CompactionReason(14)
+	CompactionReasonPeriodicCompaction         = CompactionReason(15)
+	CompactionReasonChangeTemperature          = CompactionReason(16)
+	CompactionReasonForcedBlobGC               = CompactionReason(17)
+	CompactionReasonRoundRobinTtl              = CompactionReason(18)
+	CompactionReasonRefitLevel                 = CompactionReason(19)
+)
+
+var compactionReasonNames = []string{
+	"Unknown", "LevelL0FilesNum", "LevelMaxLevelSize",
+	"UniversalSizeAmplification", "UniversalSizeRatio",
+	"UniversalSortedRunNum", "FIFOMaxSize", "FIFOReduceNumFiles", "FIFOTtl",
+	"ManualCompaction", "FilesMarkedForCompaction", "BottommostFiles", "Ttl",
+	"Flush", "ExternalSstIngestion", "PeriodicCompaction",
+	"ChangeTemperature", "ForcedBlobGC", "RoundRobinTtl", "RefitLevel",
+}
+
+func (r CompactionReason) String() string {
+	return enumName(compactionReasonNames, int(r), "CompactionReason")
+}
+
+// TableFileCreationReason is why an SST file was created.
+type TableFileCreationReason int
+
+const (
+	TableFileCreationFlush      = TableFileCreationReason(0)
+	TableFileCreationCompaction = TableFileCreationReason(1)
+	TableFileCreationRecovery   = TableFileCreationReason(2)
+	TableFileCreationMisc       = TableFileCreationReason(3)
+)
+
+var tableFileCreationReasonNames = []string{"Flush", "Compaction", "Recovery", "Misc"}
+
+func (r TableFileCreationReason) String() string {
+	return enumName(tableFileCreationReasonNames, int(r), "TableFileCreationReason")
+}
+
+// WriteStallCondition is whether writes to a column family are going
+// through, delayed or stopped.
+type WriteStallCondition int
+
+const (
+	WriteStallDelayed = WriteStallCondition(0)
+	WriteStallStopped = WriteStallCondition(1)
+	WriteStallNormal  = WriteStallCondition(2)
+)
+
+var writeStallConditionNames = []string{"delayed", "stopped", "normal"}
+
+func (c WriteStallCondition) String() string {
+	return enumName(writeStallConditionNames, int(c), "WriteStallCondition")
+}
+
+// BackgroundErrorReason is the background operation that failed.
+type BackgroundErrorReason int
+
+const (
+	BackgroundErrorFlush              = BackgroundErrorReason(0)
+	BackgroundErrorCompaction         = BackgroundErrorReason(1)
+	BackgroundErrorWriteCallback      = BackgroundErrorReason(2)
+	BackgroundErrorMemTable           = BackgroundErrorReason(3)
+	BackgroundErrorManifestWrite      = BackgroundErrorReason(4)
+	BackgroundErrorFlushNoWAL         = BackgroundErrorReason(5)
+	BackgroundErrorManifestWriteNoWAL = BackgroundErrorReason(6)
+)
+
+var backgroundErrorReasonNames = []string{
+	"Flush", "Compaction", "WriteCallback", "MemTable", "ManifestWrite",
+	"FlushNoWAL", "ManifestWriteNoWAL",
+}
+
+func (r BackgroundErrorReason) String() string {
+	return enumName(backgroundErrorReasonNames, int(r), "BackgroundErrorReason")
+}
+
+func enumName(names []string, i int, kind string) string {
+	if i >= 0 && i < len(names) {
+		return names[i]
+	}
+	return kind + "(" + strconv.Itoa(i) + ")"
+}
+
+// AddEventListener adds l to the listeners notified of the background work
+// of databases opened with these Options. l is held until the Options and
+// every database opened with them have been closed.
+func (o *Options) AddEventListener(l EventListener) {
+	C.rocksgo_options_add_event_listener(o.Opt, C.uintptr_t(eventListeners.register(l)))
+}
@@ -0,0 +1,94 @@
+package rocksgo
+
+import (
+	"sync"
+	"testing"
+)
+
+type recordingListener struct {
+	NopEventListener
+	mu       sync.Mutex
+	flushes  []FlushJobInfo
+	created  []TableFileCreationInfo
+	compacts []CompactionJobInfo
+}
+
+func (l *recordingListener) OnFlushCompleted(info FlushJobInfo) {
+	l.mu.Lock()
+	l.flushes = append(l.flushes, info)
+	l.mu.Unlock()
+}
+
+func (l *recordingListener) OnTableFileCreated(info TableFileCreationInfo) {
+	l.mu.Lock()
+	l.created = append(l.created, info)
+	l.mu.Unlock()
+}
+
+func (l *recordingListener) OnCompactionCompleted(info CompactionJobInfo) {
+	l.mu.Lock()
+	l.compacts = append(l.compacts, info)
+	l.mu.Unlock()
+}
+
+func TestEventListener(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	l := &recordingListener{}
+	options := NewOptions()
+	options.SetCreateIfMissing(true)
+	options.AddEventListener(l)
+	wo := NewWriteOptions()
+	defer wo.Close()
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	for _, k := range []string{"a", "b", "c"} {
+		if err := db.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	// CompactRange flushes the memtable before compacting.
+	if err := db.CompactRange(Range{nil, nil}); err != nil {
+		t.Fatalf("CompactRange failed: %v", err)
+	}
+	db.Close()
+	options.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.flushes) == 0 {
+		t.Fatalf("no flush was reported")
+	}
+	f := l.flushes[0]
+	if f.ColumnFamily != "default" || f.FilePath == "" || f.NumEntries != 3 {
+		t.Errorf("unexpected flush info %+v", f)
+	}
+	if len(l.created) == 0 || l.created[0].Reason != TableFileCreationFlush || l.created[0].Err != nil {
+		t.Errorf("unexpected table file creations %+v", l.created)
+	}
+	for _, c := range l.compacts {
+		if c.Err != nil {
+			t.Errorf("compaction %d failed: %v", c.JobID, c.Err)
+		}
+	}
+	if eventListeners.len() != 0 {
+		t.Errorf("the listener should be released once the Options and DB are closed")
+	}
+}
+
+func TestEventReasonStrings(t *testing.T) {
+	for _, c := range []struct {
+		got, want string
+	}{
+		{FlushReasonManualCompaction.String(), "Manual Compaction"},
+		{CompactionReasonRefitLevel.String(), "RefitLevel"},
+		{WriteStallStopped.String(), "stopped"},
+		{BackgroundErrorReason(42).String(), "BackgroundErrorReason(42)"},
+	} {
+		if c.got != c.want {
+			t.Errorf("got %q, want %q", c.got, c.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rocksgo
+
+/*
+#cgo LDFLAGS: -lrocksdb
+#include <stdint.h>
+#include "rocksdb/c.h"
+
+extern void rocksgoInfoLog(void* priv, unsigned level, char* msg, size_t len);
+
+static rocksdb_logger_t* rocksgo_logger_create(int level, uintptr_t id) {
+  return rocksdb_logger_create_callback_logger(level, rocksgoInfoLog, (void*)id);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultInfoLogQueue is the number of info log lines an InfoLogger buffers
+// for its Logger when NewInfoLogger is given no queue size.
+const DefaultInfoLogQueue = 1024
+
+// Logger receives the lines rocksdb writes to its info log, the LOG file
+// in the database directory.
+type Logger interface {
+	Log(level InfoLogLevel, msg string)
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface.
+type LoggerFunc func(level InfoLogLevel, msg string)
+
+// Log calls f(level, msg).
+func (f LoggerFunc) Log(level InfoLogLevel, msg string) {
+	f(level, msg)
+}
+
+// InfoLogger sends a database's info log to a Logger instead of the LOG
+// file. It is set on an Options with SetInfoLogger, and may be shared by
+// several.
+//
+// rocksdb logs from its background threads. So that a slow Logger never
+// stalls a flush or a compaction, lines are queued and handed to the Logger
+// from a goroutine of the InfoLogger's own. When the queue is full, lines are
+// dropped and counted; see Dropped.
+//
+// To prevent memory leaks, Close must be called on an InfoLogger when the
+// program no longer needs it, after closing the DBs using it. Lines logged
+// after Close are discarded.
+type InfoLogger struct {
+	logger  *C.rocksdb_logger_t
+	id      uintptr
+	lines   chan logLine
+	done    chan struct{}
+	dropped atomic.Uint64
+	leak    *leakRecord
+}
+
+type logLine struct {
+	level InfoLogLevel
+	msg   string
+}
+
+// NewInfoLogger creates an InfoLogger passing every line at or above level
+// to l. queueSize bounds the number of lines waiting for l; if it is zero or
+// less, DefaultInfoLogQueue is used.
+func NewInfoLogger(l Logger, level InfoLogLevel, queueSize int) *InfoLogger {
+	if queueSize <= 0 {
+		queueSize = DefaultInfoLogQueue
+	}
+	il := &InfoLogger{
+		lines: make(chan logLine, queueSize),
+		done:  make(chan struct{}),
+	}
+	go il.run(l)
+	il.id = infoLoggers.register(il)
+	il.logger = C.rocksgo_logger_create(C.int(level), C.uintptr_t(il.id))
+	il.leak = trackResource(il, "InfoLogger", nil)
+	return il
+}
+
+func (il *InfoLogger) run(l Logger) {
+	defer close(il.done)
+	for line := range il.lines {
+		l.Log(line.level, line.msg)
+	}
+}
+
+// enqueue is called from rocksdb's threads, and must never block.
+func (il *InfoLogger) enqueue(level InfoLogLevel, msg string) {
+	select {
+	case il.lines <- logLine{level, strings.TrimRight(msg, "\n")}:
+	default:
+		il.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of lines dropped so far because the Logger was
+// not keeping up.
+func (il *InfoLogger) Dropped() uint64 {
+	return il.dropped.Load()
+}
+
+// Close stops the InfoLogger, waiting for the lines already queued to be
+// handed to the Logger.
+//
+// Closing an InfoLogger more than once is a no-op.
+func (il *InfoLogger) Close() {
+	if il.logger == nil {
+		return
+	}
+	// Once unregistered, no callback can be sending on il.lines, so it is
+	// safe to close.
+	infoLoggers.unregister(il.id)
+	C.rocksdb_logger_destroy(il.logger)
+	il.logger = nil
+	close(il.lines)
+	<-il.done
+	il.leak.untrack()
+}
+
+// NewSlogLogger returns a Logger writing the info log to l, at the slog
+// level matching each line's InfoLogLevel.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return LoggerFunc(func(level InfoLogLevel, msg string) {
+		l.Log(context.Background(), level.slogLevel(), msg)
+	})
+}
+
+func (level InfoLogLevel) slogLevel() slog.Level {
+	switch level {
+	case DebugInfoLogLevel:
+		return slog.LevelDebug
+	case WarnInfoLogLevel:
+		return slog.LevelWarn
+	case ErrorInfoLogLevel:
+		return slog.LevelError
+	case FatalInfoLogLevel:
+		return slog.LevelError + 4
+	}
+	return slog.LevelInfo
+}
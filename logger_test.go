@@ -0,0 +1,75 @@
+package rocksgo
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInfoLogger(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	il := NewInfoLogger(LoggerFunc(func(level InfoLogLevel, msg string) {
+		mu.Lock()
+		lines = append(lines, msg)
+		mu.Unlock()
+	}), InfoInfoLogLevel, 0)
+	defer il.Close()
+
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	defer options.Close()
+	options.SetCreateIfMissing(true)
+	options.SetInfoLogger(il)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	db.Close()
+	il.Close()
+
+	if len(lines) == 0 {
+		t.Fatalf("no info log lines were passed to the Logger")
+	}
+	for _, l := range lines {
+		if strings.HasSuffix(l, "\n") {
+			t.Errorf("line %q should not end in a newline", l)
+		}
+	}
+	if n := il.Dropped(); n != 0 {
+		t.Errorf("%d lines were dropped", n)
+	}
+}
+
+func TestInfoLoggerQueue(t *testing.T) {
+	block := make(chan struct{})
+	il := NewInfoLogger(LoggerFunc(func(InfoLogLevel, string) {
+		<-block
+	}), InfoInfoLogLevel, 1)
+
+	// At most one line is held by the blocked Logger and one queued, so
+	// the third must be dropped rather than block the caller.
+	for i := 0; i < 3; i++ {
+		il.enqueue(InfoInfoLogLevel, "line\n")
+	}
+	if n := il.Dropped(); n < 1 {
+		t.Errorf("Dropped() = %d, want at least 1", n)
+	}
+	close(block)
+	il.Close()
+	il.Close()
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	l.Log(DebugInfoLogLevel, "hidden")
+	l.Log(WarnInfoLogLevel, "shown")
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "level=WARN msg=shown") {
+		t.Errorf("unexpected slog output %q", out)
+	}
+}
@@ -0,0 +1,153 @@
+package rocksgo
+
+/*
+#cgo LDFLAGS: -lrocksdb
+#include <stdint.h>
+#include <stdlib.h>
+#include "rocksdb/c.h"
+
+extern char* rocksgoMergeFull(uintptr_t id, char* key, size_t key_len,
+    char* existing, size_t existing_len, char** operands, size_t* operand_lens,
+    int n, unsigned char* success, size_t* new_len);
+extern char* rocksgoMergeName(uintptr_t id);
+extern void rocksgoMergeRelease(uintptr_t id);
+
+static char* rocksgo_merge_full(void* state, const char* key, size_t key_len,
+    const char* existing, size_t existing_len, const char* const* operands,
+    const size_t* operand_lens, int n, unsigned char* success,
+    size_t* new_len) {
+  return rocksgoMergeFull((uintptr_t)state, (char*)key, key_len,
+      (char*)existing, existing_len, (char**)operands, (size_t*)operand_lens,
+      n, success, new_len);
+}
+
+// Partial merges are left to rocksdb, which keeps the operands until a full
+// merge.
+static char* rocksgo_merge_partial(void* state, const char* key,
+    size_t key_len, const char* const* operands, const size_t* operand_lens,
+    int n, unsigned char* success, size_t* new_len) {
+  *success = 0;
+  *new_len = 0;
+  return NULL;
+}
+
+static void rocksgo_merge_delete_value(void* state, const char* value,
+    size_t len) {
+  free((void*)value);
+}
+
+// rocksdb expects a name whatever happens, so an operator no longer known
+// to Go, which should not be asked, still gets one.
+static const char* rocksgo_merge_name(void* state) {
+  const char* name = rocksgoMergeName((uintptr_t)state);
+  return name != NULL ? name : "rocksgo.UnknownMergeOperator";
+}
+
+static void rocksgo_merge_destroy(void* state) {
+  rocksgoMergeRelease((uintptr_t)state);
+}
+
+static rocksdb_mergeoperator_t* rocksgo_mergeoperator_create(uintptr_t id) {
+  return rocksdb_mergeoperator_create((void*)id, rocksgo_merge_destroy,
+      rocksgo_merge_full, rocksgo_merge_partial, rocksgo_merge_delete_value,
+      rocksgo_merge_name);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// MergeOperator combines the operands written with DB.Merge and
+// WriteBatch.Merge into the value of a key, letting the read-modify-write
+// of a counter or an append be done with a single blind write. It is set on
+// an Options with SetMergeOperator.
+//
+// FullMerge is called from rocksdb's threads, by reads and compactions
+// alike, and must be safe for concurrent use. It must not call back into
+// the database.
+type MergeOperator interface {
+	// Name identifies the merge operator. A database must always be
+	// opened with a merge operator of the same name.
+	Name() string
+	// FullMerge returns the value of key once operands, oldest first, are
+	// applied to its existing value. existing is nil when the key has no
+	// value. Returning false fails the read or compaction with a
+	// Corruption error.
+	//
+	// The slices given are only valid during the call.
+	FullMerge(key, existing []byte, operands [][]byte) ([]byte, bool)
+}
+
+// mergeOperator holds a MergeOperator for as long as rocksdb does, along
+// with its name as a C string, which rocksdb expects to stay valid.
+type mergeOperator struct {
+	MergeOperator
+	name *C.char
+}
+
+var mergeOperators handleRegistry[*mergeOperator]
+
+// SetMergeOperator sets the merge operator applying the operands of
+// DB.Merge and WriteBatch.Merge. Without one, merges fail.
+//
+// mo is held until the Options and every database opened with them have
+// been closed.
+// Default: nil
+func (o *Options) SetMergeOperator(mo MergeOperator) {
+	id := mergeOperators.register(&mergeOperator{mo, C.CString(mo.Name())})
+	C.rocksdb_options_set_merge_operator(o.Opt, C.rocksgo_mergeoperator_create(C.uintptr_t(id)))
+}
+
+// Merge writes a merge operand for key, to be combined with its value by the
+// MergeOperator set on the Options of the database.
+//
+// The key and value byte slices may be reused safely. Merge takes a copy of
+// them before returning.
+func (db *DB) Merge(wo *WriteOptions, key, value []byte) error {
+	if err := db.acquire(); err != nil {
+		return err
+	}
+	defer db.release()
+
+	var errStr *C.char
+	C.rocksdb_merge(db.Ldb, wo.Opt,
+		byteSliceToChar(key), C.size_t(len(key)),
+		byteSliceToChar(value), C.size_t(len(value)), &errStr)
+	return statusError(errStr)
+}
+
+// Merge queues a merge operand for key, to be written later.
+//
+// Both the key and value byte slices may be reused as WriteBatch takes a copy
+// of them before returning.
+func (w *WriteBatch) Merge(key, value []byte) {
+	C.rocksdb_writebatch_merge(w.wbatch,
+		byteSliceToChar(key), C.size_t(len(key)),
+		byteSliceToChar(value), C.size_t(len(value)))
+}
+
+// fullMerge runs a FullMerge called from C, returning the new value
+// malloc'd for rocksdb to free.
+func (m *mergeOperator) fullMerge(key *C.char, keyLen C.size_t, existing *C.char, existingLen C.size_t,
+	operands **C.char, operandLens *C.size_t, n C.int, success *C.uchar, newLen *C.size_t) *C.char {
+	var ex []byte
+	if existing != nil {
+		ex = unsafeBytes(existing, existingLen)
+		if ex == nil {
+			ex = []byte{}
+		}
+	}
+	ops := make([][]byte, n)
+	lens := unsafe.Slice(operandLens, n)
+	for i, p := range unsafe.Slice(operands, n) {
+		ops[i] = unsafeBytes(p, lens[i])
+	}
+	value, ok := m.FullMerge(unsafeBytes(key, keyLen), ex, ops)
+	*success = boolToUchar(ok)
+	if !ok {
+		*newLen = 0
+		return nil
+	}
+	*newLen = C.size_t(len(value))
+	return (*C.char)(C.CBytes(value))
+}
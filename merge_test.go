@@ -0,0 +1,91 @@
+package rocksgo
+
+import (
+	"bytes"
+	"testing"
+)
+
+// appendOperator appends the operands to the value, separated by commas.
+// An operand "fail" fails the merge.
+type appendOperator struct{}
+
+func (appendOperator) Name() string { return "rocksgo.test.append" }
+
+func (appendOperator) FullMerge(key, existing []byte, operands [][]byte) ([]byte, bool) {
+	value := append([]byte(nil), existing...)
+	for _, op := range operands {
+		if bytes.Equal(op, []byte("fail")) {
+			return nil, false
+		}
+		if len(value) > 0 {
+			value = append(value, ',')
+		}
+		value = append(value, op...)
+	}
+	return value, true
+}
+
+func TestMergeOperator(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	defer options.Close()
+	options.SetCreateIfMissing(true)
+	options.SetMergeOperator(appendOperator{})
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	defer db.Close()
+	ro := NewReadOptions()
+	defer ro.Close()
+	wo := NewWriteOptions()
+	defer wo.Close()
+
+	get := func(key string) string {
+		t.Helper()
+		v, err := db.Get(ro, []byte(key))
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", key, err)
+		}
+		return string(v)
+	}
+
+	// Merges onto a missing key, then onto an existing value.
+	for _, op := range []string{"a", "b"} {
+		if err := db.Merge(wo, []byte("new"), []byte(op)); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+	if got := get("new"); got != "a,b" {
+		t.Errorf("merging onto a missing key gave %q, want a,b", got)
+	}
+	if err := db.Put(wo, []byte("old"), []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	wb := NewWriteBatch()
+	defer wb.Close()
+	wb.Merge([]byte("old"), []byte("y"))
+	wb.Merge([]byte("old"), []byte("z"))
+	if err := db.Write(wo, wb); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := get("old"); got != "x,y,z" {
+		t.Errorf("merging a WriteBatch onto a value gave %q, want x,y,z", got)
+	}
+
+	// Compaction applies the operands for good.
+	if err := db.CompactRange(Range{}); err != nil {
+		t.Fatalf("CompactRange failed: %v", err)
+	}
+	if got := get("old"); got != "x,y,z" {
+		t.Errorf("after compaction, got %q, want x,y,z", got)
+	}
+
+	if err := db.Merge(wo, []byte("bad"), []byte("fail")); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if _, err := db.Get(ro, []byte("bad")); !IsCorruption(err) {
+		t.Errorf("a failed merge gave %v, want a Corruption error", err)
+	}
+}
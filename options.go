@@ -36,13 +36,33 @@ const (
 type InfoLogLevel uint
 
 const (
-	DebugInfoLogLevel = InfoLogLevel(0)
-	InfoInfoLogLevel  = InfoLogLevel(1)
-	WarnInfoLogLevel  = InfoLogLevel(2)
-	ErrorInfoLogLevel = InfoLogLevel(3)
-	FatalInfoLogLevel = InfoLogLevel(4)
+	DebugInfoLogLevel  = InfoLogLevel(0)
+	InfoInfoLogLevel   = InfoLogLevel(1)
+	WarnInfoLogLevel   = InfoLogLevel(2)
+	ErrorInfoLogLevel  = InfoLogLevel(3)
+	FatalInfoLogLevel  = InfoLogLevel(4)
+	HeaderInfoLogLevel = InfoLogLevel(5)
 )
 
+// String returns the name rocksdb uses for the level in its LOG file.
+func (level InfoLogLevel) String() string {
+	switch level {
+	case DebugInfoLogLevel:
+		return "DEBUG"
+	case InfoInfoLogLevel:
+		return "INFO"
+	case WarnInfoLogLevel:
+		return "WARN"
+	case ErrorInfoLogLevel:
+		return "ERROR"
+	case FatalInfoLogLevel:
+		return "FATAL"
+	case HeaderInfoLogLevel:
+		return "HEADER"
+	}
+	return "UNKNOWN"
+}
+
 // Options represent all of the available options when opening a database with
 // Open. Options should be created with NewOptions.
 //
@@ -52,7 +72,8 @@ const (
 // To prevent memory leaks, Close must be called on an Options when the
 // program no longer needs it.
 type Options struct {
-	Opt *C.rocksdb_options_t
+	Opt  *C.rocksdb_options_t
+	leak *leakRecord
 }
 
 // ReadOptions represent all of the available options when reading from a
@@ -61,7 +82,8 @@ type Options struct {
 // To prevent memory leaks, Close must called on a ReadOptions when the
 // program no longer needs it.
 type ReadOptions struct {
-	Opt *C.rocksdb_readoptions_t
+	Opt  *C.rocksdb_readoptions_t
+	leak *leakRecord
 }
 
 // WriteOptions represent all of the available options when writeing from a
@@ -70,30 +92,41 @@ type ReadOptions struct {
 // To prevent memory leaks, Close must called on a WriteOptions when the
 // program no longer needs it.
 type WriteOptions struct {
-	Opt *C.rocksdb_writeoptions_t
+	Opt  *C.rocksdb_writeoptions_t
+	leak *leakRecord
 }
 
 // NewOptions allocates a new Options object.
 func NewOptions() *Options {
-	opt := C.rocksdb_options_create()
-	return &Options{opt}
+	o := &Options{Opt: C.rocksdb_options_create()}
+	o.leak = trackResource(o, "Options", nil)
+	return o
 }
 
 // NewReadOptions allocates a new ReadOptions object.
 func NewReadOptions() *ReadOptions {
-	opt := C.rocksdb_readoptions_create()
-	return &ReadOptions{opt}
+	ro := &ReadOptions{Opt: C.rocksdb_readoptions_create()}
+	ro.leak = trackResource(ro, "ReadOptions", nil)
+	return ro
 }
 
 // NewWriteOptions allocates a new WriteOptions object.
 func NewWriteOptions() *WriteOptions {
-	opt := C.rocksdb_writeoptions_create()
-	return &WriteOptions{opt}
+	wo := &WriteOptions{Opt: C.rocksdb_writeoptions_create()}
+	wo.leak = trackResource(wo, "WriteOptions", nil)
+	return wo
 }
 
 // Close deallocates the Options, freeing its underlying C struct.
+//
+// Closing an Options more than once is a no-op.
 func (o *Options) Close() {
+	if o.Opt == nil {
+		return
+	}
 	C.rocksdb_options_destroy(o.Opt)
+	o.Opt = nil
+	o.leak.untrack()
 }
 
 // SetComparator sets the comparator to be used for all read and write
@@ -146,6 +179,12 @@ func (o *Options) SetInfoLog(log *C.rocksdb_logger_t) {
 	C.rocksdb_options_set_info_log(o.Opt, log)
 }
 
+// SetInfoLogger sends the informational log of the database to a Go Logger
+// by way of the InfoLogger given.
+func (o *Options) SetInfoLogger(il *InfoLogger) {
+	C.rocksdb_options_set_info_log(o.Opt, il.logger)
+}
+
 // SetFilterPolicy causes Open to create a new database that will uses filter
 // created from the filter policy passed in.
 func (o *Options) SetFilterPolicy(fp *FilterPolicy) {
@@ -859,8 +898,15 @@ func (self *Options) SetPlainTableFactory(keyLen uint32, bloomBitsPerKey int, ha
 }
 
 // Close deallocates the ReadOptions, freeing its underlying C struct.
+//
+// Closing a ReadOptions more than once is a no-op.
 func (ro *ReadOptions) Close() {
+	if ro.Opt == nil {
+		return
+	}
 	C.rocksdb_readoptions_destroy(ro.Opt)
+	ro.Opt = nil
+	ro.leak.untrack()
 }
 
 // SetVerifyChecksums controls whether all data read with this ReadOptions
@@ -896,9 +942,26 @@ func (ro *ReadOptions) SetSnapshot(snap *Snapshot) {
 	C.rocksdb_readoptions_set_snapshot(ro.Opt, s)
 }
 
+// SetTailing makes the Iterators created with this ReadOptions tailing
+// iterators, which see the data written after their creation. A tailing
+// Iterator that has run past the last key sees newer keys once it is
+// positioned again with a Seek. It defaults to false.
+//
+// Tailing iterators do not read from a Snapshot. See also DB.Tail.
+func (ro *ReadOptions) SetTailing(b bool) {
+	C.rocksdb_readoptions_set_tailing(ro.Opt, boolToUchar(b))
+}
+
 // Close deallocates the WriteOptions, freeing its underlying C struct.
+//
+// Closing a WriteOptions more than once is a no-op.
 func (wo *WriteOptions) Close() {
+	if wo.Opt == nil {
+		return
+	}
 	C.rocksdb_writeoptions_destroy(wo.Opt)
+	wo.Opt = nil
+	wo.leak.untrack()
 }
 
 // SetSync controls whether each write performed with this WriteOptions will
@@ -91,7 +91,12 @@ func (self *UniversalCompactionOptions) SetStopStyle(value UniversalCompactionSt
 }
 
 // Destroy deallocates the UniversalCompactionOptions object.
+//
+// Destroying a UniversalCompactionOptions more than once is a no-op.
 func (self *UniversalCompactionOptions) Destroy() {
+	if self.c == nil {
+		return
+	}
 	C.rocksdb_universal_compaction_options_destroy(self.c)
 	self.c = nil
 }
@@ -0,0 +1,240 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include <stdlib.h>
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Names of the standard database properties, for use with PropertyValue,
+// GetIntProperty and GetMapProperty.
+//
+// See the rocksdb documentation of DB::Properties for the meaning of each.
+const (
+	PropertyStats                          = "rocksdb.stats"
+	PropertySSTables                       = "rocksdb.sstables"
+	PropertyCFStats                        = "rocksdb.cfstats"
+	PropertyCFStatsNoFileHistogram         = "rocksdb.cfstats-no-file-histogram"
+	PropertyCFFileHistogram                = "rocksdb.cf-file-histogram"
+	PropertyDBStats                        = "rocksdb.dbstats"
+	PropertyLevelStats                     = "rocksdb.levelstats"
+	PropertyNumImmutableMemTable           = "rocksdb.num-immutable-mem-table"
+	PropertyNumImmutableMemTableFlushed    = "rocksdb.num-immutable-mem-table-flushed"
+	PropertyMemTableFlushPending           = "rocksdb.mem-table-flush-pending"
+	PropertyNumRunningFlushes              = "rocksdb.num-running-flushes"
+	PropertyCompactionPending              = "rocksdb.compaction-pending"
+	PropertyNumRunningCompactions          = "rocksdb.num-running-compactions"
+	PropertyBackgroundErrors               = "rocksdb.background-errors"
+	PropertyCurSizeActiveMemTable          = "rocksdb.cur-size-active-mem-table"
+	PropertyCurSizeAllMemTables            = "rocksdb.cur-size-all-mem-tables"
+	PropertySizeAllMemTables               = "rocksdb.size-all-mem-tables"
+	PropertyNumEntriesActiveMemTable       = "rocksdb.num-entries-active-mem-table"
+	PropertyNumEntriesImmMemTables         = "rocksdb.num-entries-imm-mem-tables"
+	PropertyNumDeletesActiveMemTable       = "rocksdb.num-deletes-active-mem-table"
+	PropertyNumDeletesImmMemTables         = "rocksdb.num-deletes-imm-mem-tables"
+	PropertyEstimateNumKeys                = "rocksdb.estimate-num-keys"
+	PropertyEstimateTableReadersMem        = "rocksdb.estimate-table-readers-mem"
+	PropertyIsFileDeletionsEnabled         = "rocksdb.is-file-deletions-enabled"
+	PropertyNumSnapshots                   = "rocksdb.num-snapshots"
+	PropertyOldestSnapshotTime             = "rocksdb.oldest-snapshot-time"
+	PropertyNumLiveVersions                = "rocksdb.num-live-versions"
+	PropertyCurrentSuperVersionNumber      = "rocksdb.current-super-version-number"
+	PropertyEstimateLiveDataSize           = "rocksdb.estimate-live-data-size"
+	PropertyMinLogNumberToKeep             = "rocksdb.min-log-number-to-keep"
+	PropertyTotalSSTFilesSize              = "rocksdb.total-sst-files-size"
+	PropertyLiveSSTFilesSize               = "rocksdb.live-sst-files-size"
+	PropertyBaseLevel                      = "rocksdb.base-level"
+	PropertyEstimatePendingCompactionBytes = "rocksdb.estimate-pending-compaction-bytes"
+	PropertyAggregatedTableProperties      = "rocksdb.aggregated-table-properties"
+	PropertyActualDelayedWriteRate         = "rocksdb.actual-delayed-write-rate"
+	PropertyIsWriteStopped                 = "rocksdb.is-write-stopped"
+	PropertyEstimateOldestKeyTime          = "rocksdb.estimate-oldest-key-time"
+	PropertyBlockCacheCapacity             = "rocksdb.block-cache-capacity"
+	PropertyBlockCacheUsage                = "rocksdb.block-cache-usage"
+	PropertyBlockCachePinnedUsage          = "rocksdb.block-cache-pinned-usage"
+
+	// PropertyNumFilesAtLevelPrefix and PropertyCompressionRatioAtLevelPrefix
+	// are followed by a level number. See PropertyNumFilesAtLevel and
+	// PropertyCompressionRatioAtLevel.
+	PropertyNumFilesAtLevelPrefix         = "rocksdb.num-files-at-level"
+	PropertyCompressionRatioAtLevelPrefix = "rocksdb.compression-ratio-at-level"
+)
+
+// maxPropertyLevels bounds the per-level property lookups done by
+// DB.Properties. rocksdb databases rarely have more than 7 levels.
+const maxPropertyLevels = 64
+
+// PropertyNumFilesAtLevel returns the name of the property holding the
+// number of files at the given level, e.g. "rocksdb.num-files-at-level0".
+func PropertyNumFilesAtLevel(level int) string {
+	return PropertyNumFilesAtLevelPrefix + strconv.Itoa(level)
+}
+
+// PropertyCompressionRatioAtLevel returns the name of the property holding the
+// compression ratio of the files at the given level.
+func PropertyCompressionRatioAtLevel(level int) string {
+	return PropertyCompressionRatioAtLevelPrefix + strconv.Itoa(level)
+}
+
+// GetIntProperty returns the value of a numeric database property.
+//
+// The boolean is false if the property is unknown or its value is not a
+// number. Properties rocksdb only reports as strings, such as
+// "rocksdb.num-files-at-level0", are parsed.
+func (db *DB) GetIntProperty(propName string) (uint64, bool) {
+	if n, ok := db.intProperty(propName); ok {
+		return n, true
+	}
+
+	s, ok := db.getProperty(propName)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func (db *DB) intProperty(propName string) (uint64, bool) {
+	if db.acquire() != nil {
+		return 0, false
+	}
+	defer db.release()
+
+	cname := C.CString(propName)
+	defer C.rocksdb_free(unsafe.Pointer(cname))
+
+	var value C.uint64_t
+	if C.rocksdb_property_int(db.Ldb, cname, &value) != 0 {
+		return 0, false
+	}
+	return uint64(value), true
+}
+
+// GetMapProperty returns the value of a map-valued database property, such
+// as "rocksdb.cfstats" or "rocksdb.aggregated-table-properties".
+//
+// The boolean is false if the property is unknown or is not a map property.
+func (db *DB) GetMapProperty(propName string) (map[string]string, bool) {
+	if db.acquire() != nil {
+		return nil, false
+	}
+	defer db.release()
+
+	cname := C.CString(propName)
+	defer C.rocksdb_free(unsafe.Pointer(cname))
+
+	var n C.size_t
+	buf := C.rocksgo_property_map(db.Ldb, cname, &n)
+	if buf == nil {
+		return nil, false
+	}
+	defer C.rocksdb_free(unsafe.Pointer(buf))
+
+	// The buffer holds NUL-terminated keys and values, one after the other.
+	fields := strings.Split(string(C.GoBytes(unsafe.Pointer(buf), C.int(n))), "\x00")
+	m := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		m[fields[i]] = fields[i+1]
+	}
+	return m, true
+}
+
+// Properties is a snapshot of the most commonly monitored database
+// properties, as collected by DB.Properties.
+//
+// A field is left at zero if the database does not report the property.
+type Properties struct {
+	EstimateNumKeys                uint64
+	EstimateLiveDataSize           uint64
+	EstimateTableReadersMem        uint64
+	EstimatePendingCompactionBytes uint64
+	CurSizeActiveMemTable          uint64
+	CurSizeAllMemTables            uint64
+	SizeAllMemTables               uint64
+	NumEntriesActiveMemTable       uint64
+	NumEntriesImmMemTables         uint64
+	NumDeletesActiveMemTable       uint64
+	NumDeletesImmMemTables         uint64
+	NumImmutableMemTable           uint64
+	NumImmutableMemTableFlushed    uint64
+	MemTableFlushPending           uint64
+	NumRunningFlushes              uint64
+	CompactionPending              uint64
+	NumRunningCompactions          uint64
+	BackgroundErrors               uint64
+	NumSnapshots                   uint64
+	OldestSnapshotTime             uint64
+	NumLiveVersions                uint64
+	TotalSSTFilesSize              uint64
+	LiveSSTFilesSize               uint64
+	BaseLevel                      uint64
+	ActualDelayedWriteRate         uint64
+	IsWriteStopped                 uint64
+	BlockCacheCapacity             uint64
+	BlockCacheUsage                uint64
+	BlockCachePinnedUsage          uint64
+
+	// NumFilesAtLevel holds the number of files at each level, starting at
+	// level 0.
+	NumFilesAtLevel []uint64
+}
+
+// Properties collects the standard numeric properties of the database into
+// a Properties struct.
+func (db *DB) Properties() Properties {
+	var p Properties
+	for _, f := range []struct {
+		name string
+		dst  *uint64
+	}{
+		{PropertyEstimateNumKeys, &p.EstimateNumKeys},
+		{PropertyEstimateLiveDataSize, &p.EstimateLiveDataSize},
+		{PropertyEstimateTableReadersMem, &p.EstimateTableReadersMem},
+		{PropertyEstimatePendingCompactionBytes, &p.EstimatePendingCompactionBytes},
+		{PropertyCurSizeActiveMemTable, &p.CurSizeActiveMemTable},
+		{PropertyCurSizeAllMemTables, &p.CurSizeAllMemTables},
+		{PropertySizeAllMemTables, &p.SizeAllMemTables},
+		{PropertyNumEntriesActiveMemTable, &p.NumEntriesActiveMemTable},
+		{PropertyNumEntriesImmMemTables, &p.NumEntriesImmMemTables},
+		{PropertyNumDeletesActiveMemTable, &p.NumDeletesActiveMemTable},
+		{PropertyNumDeletesImmMemTables, &p.NumDeletesImmMemTables},
+		{PropertyNumImmutableMemTable, &p.NumImmutableMemTable},
+		{PropertyNumImmutableMemTableFlushed, &p.NumImmutableMemTableFlushed},
+		{PropertyMemTableFlushPending, &p.MemTableFlushPending},
+		{PropertyNumRunningFlushes, &p.NumRunningFlushes},
+		{PropertyCompactionPending, &p.CompactionPending},
+		{PropertyNumRunningCompactions, &p.NumRunningCompactions},
+		{PropertyBackgroundErrors, &p.BackgroundErrors},
+		{PropertyNumSnapshots, &p.NumSnapshots},
+		{PropertyOldestSnapshotTime, &p.OldestSnapshotTime},
+		{PropertyNumLiveVersions, &p.NumLiveVersions},
+		{PropertyTotalSSTFilesSize, &p.TotalSSTFilesSize},
+		{PropertyLiveSSTFilesSize, &p.LiveSSTFilesSize},
+		{PropertyBaseLevel, &p.BaseLevel},
+		{PropertyActualDelayedWriteRate, &p.ActualDelayedWriteRate},
+		{PropertyIsWriteStopped, &p.IsWriteStopped},
+		{PropertyBlockCacheCapacity, &p.BlockCacheCapacity},
+		{PropertyBlockCacheUsage, &p.BlockCacheUsage},
+		{PropertyBlockCachePinnedUsage, &p.BlockCachePinnedUsage},
+	} {
+		*f.dst, _ = db.GetIntProperty(f.name)
+	}
+
+	for level := 0; level < maxPropertyLevels; level++ {
+		n, ok := db.GetIntProperty(PropertyNumFilesAtLevel(level))
+		if !ok {
+			break
+		}
+		p.NumFilesAtLevel = append(p.NumFilesAtLevel, n)
+	}
+	return p
+}
@@ -0,0 +1,259 @@
+// Shims for the parts of the rocksdb C++ API that the C API in
+// rocksdb/c.h does not expose.
+
+#include <chrono>
+#include <map>
+#include <memory>
+#include <string>
+#include <vector>
+
+#include "rocksdb/listener.h"
+#include "rocksgo_internal.h"
+
+using rocksdb::BackgroundErrorReason;
+using rocksdb::CompactionJobInfo;
+using rocksdb::DB;
+using rocksdb::EventListener;
+using rocksdb::FlushJobInfo;
+using rocksdb::Iterator;
+using rocksdb::Options;
+using rocksdb::ReadOptions;
+using rocksdb::Slice;
+using rocksdb::Status;
+using rocksdb::TableFileCreationInfo;
+using rocksdb::TableFileDeletionInfo;
+using rocksdb::WriteBatch;
+using rocksdb::WriteStallInfo;
+
+namespace {
+
+// StatusString holds the text handed to Go for a status: NULL when it is
+// OK, and its ToString() otherwise.
+class StatusString {
+ public:
+  explicit StatusString(const Status& s) : ok_(s.ok()) {
+    if (!ok_) {
+      str_ = s.ToString();
+    }
+  }
+  const char* c_str() const { return ok_ ? NULL : str_.c_str(); }
+
+ private:
+  bool ok_;
+  std::string str_;
+};
+
+ReadOptions WithDeadline(const rocksdb_readoptions_t* ro, uint64_t deadline_us,
+                         uint64_t io_timeout_us) {
+  ReadOptions opts = ro->rep;
+  opts.deadline = std::chrono::microseconds(deadline_us);
+  opts.io_timeout = std::chrono::microseconds(io_timeout_us);
+  return opts;
+}
+
+std::vector<const char*> CStrings(const std::vector<std::string>& v) {
+  std::vector<const char*> out;
+  out.reserve(v.size());
+  for (const auto& s : v) {
+    out.push_back(s.c_str());
+  }
+  return out;
+}
+
+// AppendHandler replays the updates of a WriteBatch into another. rocksgo
+// only writes to the default column family, so any other is refused.
+class AppendHandler : public WriteBatch::Handler {
+ public:
+  explicit AppendHandler(WriteBatch* dst) : dst_(dst) {}
+
+  Status PutCF(uint32_t cf, const Slice& key, const Slice& value) override {
+    return cf == 0 ? dst_->Put(key, value) : Unsupported();
+  }
+  Status DeleteCF(uint32_t cf, const Slice& key) override {
+    return cf == 0 ? dst_->Delete(key) : Unsupported();
+  }
+  Status SingleDeleteCF(uint32_t cf, const Slice& key) override {
+    return cf == 0 ? dst_->SingleDelete(key) : Unsupported();
+  }
+  Status DeleteRangeCF(uint32_t cf, const Slice& begin,
+                       const Slice& end) override {
+    return cf == 0 ? dst_->DeleteRange(begin, end) : Unsupported();
+  }
+  Status MergeCF(uint32_t cf, const Slice& key, const Slice& value) override {
+    return cf == 0 ? dst_->Merge(key, value) : Unsupported();
+  }
+  void LogData(const Slice& blob) override { dst_->PutLogData(blob); }
+
+ private:
+  static Status Unsupported() {
+    return Status::NotSupported("column families other than the default");
+  }
+
+  WriteBatch* dst_;
+};
+
+// GoEventListener forwards rocksdb's events to a Go EventListener. It is
+// shared by the Options it was added to and every copy of them, including
+// those held by open databases, and releases the Go side once the last of
+// them is gone.
+class GoEventListener : public EventListener {
+ public:
+  explicit GoEventListener(uintptr_t id) : id_(id) {}
+  ~GoEventListener() override { rocksgoListenerRelease(id_); }
+
+  const char* Name() const override { return "rocksgo.GoEventListener"; }
+
+  void OnFlushCompleted(DB* /*db*/, const FlushJobInfo& fi) override {
+    rocksgo_flush_job_info_t info;
+    info.cf_name = fi.cf_name.c_str();
+    info.file_path = fi.file_path.c_str();
+    info.job_id = fi.job_id;
+    info.reason = static_cast<int>(fi.flush_reason);
+    info.smallest_seqno = fi.smallest_seqno;
+    info.largest_seqno = fi.largest_seqno;
+    info.num_entries = fi.table_properties.num_entries;
+    info.data_size = fi.table_properties.data_size;
+    info.triggered_writes_slowdown = fi.triggered_writes_slowdown;
+    info.triggered_writes_stop = fi.triggered_writes_stop;
+    rocksgoOnFlushCompleted(id_, &info);
+  }
+
+  void OnCompactionCompleted(DB* /*db*/, const CompactionJobInfo& ci) override {
+    StatusString status(ci.status);
+    std::vector<const char*> inputs = CStrings(ci.input_files);
+    std::vector<const char*> outputs = CStrings(ci.output_files);
+    rocksgo_compaction_job_info_t info;
+    info.cf_name = ci.cf_name.c_str();
+    info.status = status.c_str();
+    info.job_id = ci.job_id;
+    info.reason = static_cast<int>(ci.compaction_reason);
+    info.base_input_level = ci.base_input_level;
+    info.output_level = ci.output_level;
+    info.input_files = inputs.data();
+    info.num_input_files = inputs.size();
+    info.output_files = outputs.data();
+    info.num_output_files = outputs.size();
+    info.elapsed_micros = ci.stats.elapsed_micros;
+    info.total_input_bytes = ci.stats.total_input_bytes;
+    info.total_output_bytes = ci.stats.total_output_bytes;
+    info.num_input_records = ci.stats.num_input_records;
+    info.num_output_records = ci.stats.num_output_records;
+    rocksgoOnCompactionCompleted(id_, &info);
+  }
+
+  void OnTableFileCreated(const TableFileCreationInfo& ti) override {
+    StatusString status(ti.status);
+    rocksgo_table_file_creation_info_t info;
+    info.db_name = ti.db_name.c_str();
+    info.cf_name = ti.cf_name.c_str();
+    info.file_path = ti.file_path.c_str();
+    info.status = status.c_str();
+    info.job_id = ti.job_id;
+    info.reason = static_cast<int>(ti.reason);
+    info.file_size = ti.file_size;
+    info.num_entries = ti.table_properties.num_entries;
+    rocksgoOnTableFileCreated(id_, &info);
+  }
+
+  void OnTableFileDeleted(const TableFileDeletionInfo& ti) override {
+    StatusString status(ti.status);
+    rocksgo_table_file_deletion_info_t info;
+    info.db_name = ti.db_name.c_str();
+    info.file_path = ti.file_path.c_str();
+    info.status = status.c_str();
+    info.job_id = ti.job_id;
+    rocksgoOnTableFileDeleted(id_, &info);
+  }
+
+  void OnStallConditionsChanged(const WriteStallInfo& wi) override {
+    rocksgoOnStallConditionsChanged(id_, const_cast<char*>(wi.cf_name.c_str()),
+                                    static_cast<int>(wi.condition.cur),
+                                    static_cast<int>(wi.condition.prev));
+  }
+
+  void OnBackgroundError(BackgroundErrorReason reason,
+                         Status* bg_error) override {
+    StatusString status(*bg_error);
+    rocksgoOnBackgroundError(id_, static_cast<int>(reason),
+                             const_cast<char*>(status.c_str()));
+  }
+
+ private:
+  uintptr_t id_;
+};
+
+}  // namespace
+
+extern "C" {
+
+char* rocksgo_property_map(rocksdb_t* db, const char* propname, size_t* len) {
+  std::map<std::string, std::string> props;
+  if (!db->rep->GetMapProperty(propname, &props)) {
+    *len = 0;
+    return NULL;
+  }
+  size_t n = 0;
+  for (const auto& kv : props) {
+    n += kv.first.size() + 1 + kv.second.size() + 1;
+  }
+  // Always hand back a non-NULL buffer so that an empty map can be told
+  // apart from an unknown property.
+  char* buf = static_cast<char*>(malloc(n > 0 ? n : 1));
+  char* p = buf;
+  for (const auto& kv : props) {
+    memcpy(p, kv.first.c_str(), kv.first.size() + 1);
+    p += kv.first.size() + 1;
+    memcpy(p, kv.second.c_str(), kv.second.size() + 1);
+    p += kv.second.size() + 1;
+  }
+  *len = n;
+  return buf;
+}
+
+char* rocksgo_get_deadline(rocksdb_t* db, const rocksdb_readoptions_t* ro,
+                           uint64_t deadline_us, uint64_t io_timeout_us,
+                           const char* key, size_t keylen, size_t* vallen,
+                           char** errptr) {
+  std::string value;
+  Status s = db->rep->Get(WithDeadline(ro, deadline_us, io_timeout_us),
+                          Slice(key, keylen), &value);
+  if (!s.ok()) {
+    *vallen = 0;
+    if (!s.IsNotFound()) {
+      rocksgo::SaveError(errptr, s);
+    }
+    return NULL;
+  }
+  *vallen = value.size();
+  char* buf = static_cast<char*>(malloc(value.size() > 0 ? value.size() : 1));
+  memcpy(buf, value.data(), value.size());
+  return buf;
+}
+
+rocksdb_iterator_t* rocksgo_create_iterator_deadline(
+    rocksdb_t* db, const rocksdb_readoptions_t* ro, uint64_t deadline_us,
+    uint64_t io_timeout_us) {
+  rocksdb_iterator_t* it = new rocksdb_iterator_t;
+  it->rep = db->rep->NewIterator(WithDeadline(ro, deadline_us, io_timeout_us));
+  return it;
+}
+
+void rocksgo_writebatch_append(rocksdb_writebatch_t* dst,
+                               rocksdb_writebatch_t* src, char** errptr) {
+  // A batch that cannot be appended whole is not appended at all.
+  dst->rep.SetSavePoint();
+  AppendHandler handler(&dst->rep);
+  Status s = src->rep.Iterate(&handler);
+  if (!s.ok()) {
+    dst->rep.RollbackToSavePoint();
+    rocksgo::SaveError(errptr, s);
+    return;
+  }
+  dst->rep.PopSavePoint();
+}
+
+void rocksgo_options_add_event_listener(rocksdb_options_t* opt, uintptr_t id) {
+  opt->rep.listeners.push_back(std::make_shared<GoEventListener>(id));
+}
+
+}  // extern "C"
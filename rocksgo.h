@@ -1,6 +1,199 @@
 #ifndef ROCKSGO_H
 #define ROCKSGO_H
 
+#include <stddef.h>
+#include <stdint.h>
+#include "rocksdb/c.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
 void rocksdb_free(void* ptr);
 
-#endif
\ No newline at end of file
+// rocksgo_property_map returns the map property propname serialized as
+// NUL-terminated key and value strings, one after the other, in a single
+// malloc'd buffer of *len bytes. It returns NULL if the property is not a
+// known map property.
+char* rocksgo_property_map(rocksdb_t* db, const char* propname, size_t* len);
+
+// The rocksgo_*_info_t structs flatten the info rocksdb::EventListener
+// callbacks receive. Their strings are only valid for the duration of the
+// callback. A status is NULL when it is OK, and its ToString() otherwise.
+
+typedef struct {
+  const char* cf_name;
+  const char* file_path;
+  int job_id;
+  int reason;
+  uint64_t smallest_seqno;
+  uint64_t largest_seqno;
+  uint64_t num_entries;
+  uint64_t data_size;
+  unsigned char triggered_writes_slowdown;
+  unsigned char triggered_writes_stop;
+} rocksgo_flush_job_info_t;
+
+typedef struct {
+  const char* cf_name;
+  const char* status;
+  int job_id;
+  int reason;
+  int base_input_level;
+  int output_level;
+  const char** input_files;
+  size_t num_input_files;
+  const char** output_files;
+  size_t num_output_files;
+  uint64_t elapsed_micros;
+  uint64_t total_input_bytes;
+  uint64_t total_output_bytes;
+  uint64_t num_input_records;
+  uint64_t num_output_records;
+} rocksgo_compaction_job_info_t;
+
+typedef struct {
+  const char* db_name;
+  const char* cf_name;
+  const char* file_path;
+  const char* status;
+  int job_id;
+  int reason;
+  uint64_t file_size;
+  uint64_t num_entries;
+} rocksgo_table_file_creation_info_t;
+
+typedef struct {
+  const char* db_name;
+  const char* file_path;
+  const char* status;
+  int job_id;
+} rocksgo_table_file_deletion_info_t;
+
+// rocksgo_get_deadline is rocksdb_get with the deadline and io_timeout of
+// the ReadOptions overridden, both in microseconds and 0 meaning none. The
+// deadline is counted from the Unix epoch.
+char* rocksgo_get_deadline(rocksdb_t* db, const rocksdb_readoptions_t* ro,
+                           uint64_t deadline_us, uint64_t io_timeout_us,
+                           const char* key, size_t keylen, size_t* vallen,
+                           char** errptr);
+
+// rocksgo_create_iterator_deadline is rocksdb_create_iterator with the
+// deadline and io_timeout of the ReadOptions overridden, as for
+// rocksgo_get_deadline.
+rocksdb_iterator_t* rocksgo_create_iterator_deadline(
+    rocksdb_t* db, const rocksdb_readoptions_t* ro, uint64_t deadline_us,
+    uint64_t io_timeout_us);
+
+// rocksgo_writebatch_append appends every update in src to dst, or none of
+// them if one cannot be.
+void rocksgo_writebatch_append(rocksdb_writebatch_t* dst,
+                               rocksdb_writebatch_t* src, char** errptr);
+
+// rocksgo_options_add_event_listener adds to opt a rocksdb::EventListener
+// forwarding every event to the Go EventListener registered under id. The
+// id is released by a call to rocksgoListenerRelease once rocksdb no longer
+// holds the listener.
+void rocksgo_options_add_event_listener(rocksdb_options_t* opt, uintptr_t id);
+
+// The operations a fault env injects errors into.
+#define ROCKSGO_FAULT_WRITE 0
+#define ROCKSGO_FAULT_READ 1
+#define ROCKSGO_FAULT_SYNC 2
+#define ROCKSGO_FAULT_OPS 3
+
+// rocksgo_fault_env_create returns an Env wrapping the default one, which
+// injects the faults set with the functions below, drawing the random ones
+// from a generator seeded with seed. It is destroyed with
+// rocksdb_env_destroy.
+rocksdb_env_t* rocksgo_fault_env_create(uint64_t seed);
+void rocksgo_fault_env_set_filesystem_active(rocksdb_env_t* env,
+                                             unsigned char active);
+// rocksgo_fault_env_fail_after lets n more operations of kind op succeed,
+// and fails every one after them. A negative n never fails.
+void rocksgo_fault_env_fail_after(rocksdb_env_t* env, int op, int64_t n);
+void rocksgo_fault_env_fail_with_probability(rocksdb_env_t* env, int op,
+                                             double p);
+// rocksgo_fault_env_set_free_space fails writes with NoSpace once bytes
+// more have been written. A negative bytes never does.
+void rocksgo_fault_env_set_free_space(rocksdb_env_t* env, int64_t bytes);
+void rocksgo_fault_env_drop_unsynced_data(rocksdb_env_t* env, char** errptr);
+
+// The length of the plaintext prefix an encrypted env writes at the start
+// of every file, holding its key ID and IV, and the block size of its
+// cipher.
+#define ROCKSGO_ENCRYPTION_PREFIX 4096
+#define ROCKSGO_CIPHER_BLOCK_SIZE 16
+
+// rocksgo_encrypted_env_create returns an Env wrapping the default one,
+// which encrypts every file with the keys of the Go KeyProvider registered
+// under id. The id is released by a call to rocksgoEncryptionRelease once
+// the Env is destroyed with rocksdb_env_destroy.
+rocksdb_env_t* rocksgo_encrypted_env_create(uintptr_t id);
+
+// rocksgo_sst_file_reader_t reads a single SST file, outside of any
+// database.
+typedef struct rocksgo_sst_file_reader_t rocksgo_sst_file_reader_t;
+
+// rocksgo_table_properties_t flattens rocksdb::TableProperties. Its
+// strings are malloc'd, and freed by rocksgo_table_properties_free.
+typedef struct {
+  uint64_t num_entries;
+  uint64_t num_deletions;
+  uint64_t num_merge_operands;
+  uint64_t num_range_deletions;
+  uint64_t raw_key_size;
+  uint64_t raw_value_size;
+  uint64_t data_size;
+  uint64_t index_size;
+  uint64_t filter_size;
+  uint64_t num_data_blocks;
+  uint64_t format_version;
+  uint64_t oldest_key_time;
+  uint64_t file_creation_time;
+  char* column_family_name;
+  char* comparator_name;
+  char* compression_name;
+  char* filter_policy_name;
+} rocksgo_table_properties_t;
+
+rocksgo_sst_file_reader_t* rocksgo_sst_file_reader_create(
+    const rocksdb_options_t* opt);
+void rocksgo_sst_file_reader_open(rocksgo_sst_file_reader_t* r,
+                                  const char* path, char** errptr);
+void rocksgo_sst_file_reader_destroy(rocksgo_sst_file_reader_t* r);
+// rocksgo_sst_file_reader_new_iterator returns an iterator over the file,
+// to be destroyed with rocksdb_iter_destroy before the reader.
+rocksdb_iterator_t* rocksgo_sst_file_reader_new_iterator(
+    rocksgo_sst_file_reader_t* r, const rocksdb_readoptions_t* ro);
+void rocksgo_sst_file_reader_verify_checksum(rocksgo_sst_file_reader_t* r,
+                                             const rocksdb_readoptions_t* ro,
+                                             char** errptr);
+void rocksgo_sst_file_reader_get_properties(rocksgo_sst_file_reader_t* r,
+                                            rocksgo_table_properties_t* props);
+void rocksgo_table_properties_free(rocksgo_table_properties_t* props);
+
+// Implemented in Go, in callbacks.go.
+extern void rocksgoOnFlushCompleted(uintptr_t id, rocksgo_flush_job_info_t* info);
+extern void rocksgoOnCompactionCompleted(uintptr_t id, rocksgo_compaction_job_info_t* info);
+extern void rocksgoOnTableFileCreated(uintptr_t id, rocksgo_table_file_creation_info_t* info);
+extern void rocksgoOnTableFileDeleted(uintptr_t id, rocksgo_table_file_deletion_info_t* info);
+extern void rocksgoOnStallConditionsChanged(uintptr_t id, char* cf_name, int cur, int prev);
+extern void rocksgoOnBackgroundError(uintptr_t id, int reason, char* status);
+extern void rocksgoListenerRelease(uintptr_t id);
+
+// The encryption callbacks return NULL on success, and otherwise an error
+// message to be freed with free().
+extern char* rocksgoEncryptionNewPrefix(uintptr_t id, char* prefix, size_t len);
+extern char* rocksgoEncryptionOpenStream(uintptr_t id, char* prefix, size_t len,
+                                         uintptr_t* stream);
+extern void rocksgoEncryptionRelease(uintptr_t id);
+extern char* rocksgoCipherStreamXOR(uintptr_t id, uint64_t offset, char* data,
+                                    size_t len);
+extern void rocksgoCipherStreamRelease(uintptr_t id);
+
+#ifdef __cplusplus
+}  // extern "C"
+#endif
+
+#endif
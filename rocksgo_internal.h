@@ -0,0 +1,50 @@
+// Definitions shared by the C++ shims, and not part of the C interface in
+// rocksgo.h.
+
+#ifndef ROCKSGO_INTERNAL_H
+#define ROCKSGO_INTERNAL_H
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "rocksdb/db.h"
+#include "rocksdb/env.h"
+#include "rocksdb/options.h"
+#include "rocksdb/write_batch.h"
+#include "rocksgo.h"
+
+// These mirror the private definitions in rocksdb's db/c.cc so that the
+// handles created by the C API can be unwrapped, and new ones made.
+struct rocksdb_t {
+  rocksdb::DB* rep;
+};
+struct rocksdb_options_t {
+  rocksdb::Options rep;
+};
+struct rocksdb_iterator_t {
+  rocksdb::Iterator* rep;
+};
+struct rocksdb_writebatch_t {
+  rocksdb::WriteBatch rep;
+};
+// Only the leading ReadOptions of rocksdb_readoptions_t is mirrored; the
+// iterator bounds that follow it are never touched here.
+struct rocksdb_readoptions_t {
+  rocksdb::ReadOptions rep;
+};
+struct rocksdb_env_t {
+  rocksdb::Env* rep;
+  bool is_default;
+};
+
+namespace rocksgo {
+
+// SaveError reports a failed status through a C API style errptr.
+inline void SaveError(char** errptr, const rocksdb::Status& s) {
+  free(*errptr);
+  *errptr = strdup(s.ToString().c_str());
+}
+
+}  // namespace rocksgo
+
+#endif
@@ -0,0 +1,610 @@
+package rocksgotest
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ananclub/rocksgo"
+)
+
+// ModelConfig configures CheckModel. The zero value checks a database with
+// the default options and bytewise keys.
+type ModelConfig struct {
+	// Configure, if set, changes the Options of the database before it is
+	// opened, to set a comparator or a merge operator for example. The Env
+	// must be left alone.
+	Configure func(*rocksgo.Options)
+	// Compare orders keys the way the comparator of the database does.
+	// Default: bytes.Compare
+	Compare func(a, b []byte) int
+	// Merge, if set, models the merge operator of the database, and adds
+	// Merge operations to the sequences run. It returns the value of key
+	// once operand is applied to existing, which is nil when the key has no
+	// value.
+	Merge func(key, existing, operand []byte) []byte
+	// Key draws the key of an operation. Drawing from a small set of keys
+	// makes operations on the same key likely.
+	// Default: one of 32 keys from "key00" to "key31"
+	Key func(r *rand.Rand) []byte
+	// Value draws the value of a Put or the operand of a Merge.
+	// Default: a short string, sometimes empty
+	Value func(r *rand.Rand) []byte
+	// Seed seeds the random sequences. The seed is reported on failure, so
+	// that the same sequences can be run again.
+	// Default: the current time
+	Seed int64
+	// Runs is the number of random sequences run, each against a new
+	// database.
+	// Default: 50
+	Runs int
+	// Steps is the number of operations in each sequence.
+	// Default: 200
+	Steps int
+}
+
+// CheckModel runs random sequences of operations against a database kept
+// in memory and against a sorted map modelling it, and fails tb as soon as
+// they disagree.
+//
+// The operations are Put, Delete and Merge, WriteBatches of them, Get,
+// NewSnapshot and ReleaseSnapshot, reads from the snapshots, iterators with
+// their seeks, Next and Prev, CompactRange and closing and reopening the
+// database. Every result observable through rocksgo is compared with the
+// model's. A failing sequence is shrunk to a minimal one still failing,
+// which is reported along with the seed.
+func CheckModel(tb testing.TB, cfg ModelConfig) {
+	tb.Helper()
+	cfg.setDefaults()
+	for run := 0; run < cfg.Runs; run++ {
+		seed := cfg.Seed + int64(run)
+		ops := cfg.generate(rand.New(rand.NewSource(seed)))
+		f := cfg.run(tb, ops)
+		if f == nil {
+			continue
+		}
+		ops, f = cfg.shrink(tb, ops, f)
+		var b strings.Builder
+		for i, o := range ops {
+			fmt.Fprintf(&b, "\t%d: %v\n", i, o)
+		}
+		tb.Fatalf("rocksgotest: the database disagrees with the model (seed %d): %s\nafter these %d operations:\n%s",
+			seed, f.msg, len(ops), b.String())
+		return
+	}
+}
+
+func (cfg *ModelConfig) setDefaults() {
+	if cfg.Compare == nil {
+		cfg.Compare = bytes.Compare
+	}
+	if cfg.Key == nil {
+		cfg.Key = func(r *rand.Rand) []byte {
+			return fmt.Appendf(nil, "key%02d", r.Intn(32))
+		}
+	}
+	if cfg.Value == nil {
+		cfg.Value = func(r *rand.Rand) []byte {
+			if r.Intn(10) == 0 {
+				return []byte{}
+			}
+			return fmt.Appendf(nil, "%x", r.Int63n(1<<24))
+		}
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if cfg.Runs <= 0 {
+		cfg.Runs = 50
+	}
+	if cfg.Steps <= 0 {
+		cfg.Steps = 200
+	}
+}
+
+type opKind int
+
+const (
+	opPut opKind = iota
+	opDelete
+	opMerge
+	opBatch
+	opGet
+	opSnapshot
+	opReleaseSnapshot
+	opNewIterator
+	opCloseIterator
+	opSeekToFirst
+	opSeekToLast
+	opSeek
+	opSeekForPrev
+	opNext
+	opPrev
+	opCompactRange
+	opReopen
+)
+
+// op is one operation of a sequence. Snapshots and iterators are referred
+// to by their index, modulo the number open, so that a sequence stays valid
+// whatever operations shrinking removes from it; an operation on a snapshot
+// or iterator when none is open does nothing.
+type op struct {
+	kind  opKind
+	key   []byte
+	value []byte
+	// n is the index of the snapshot or iterator, or -1 for reads and
+	// iterators not using a snapshot.
+	n     int
+	batch []op
+}
+
+func (o op) String() string {
+	snap := func() string {
+		if o.n < 0 {
+			return ""
+		}
+		return fmt.Sprintf(", snapshot %d", o.n)
+	}
+	switch o.kind {
+	case opPut:
+		return fmt.Sprintf("Put(%q, %q)", o.key, o.value)
+	case opDelete:
+		return fmt.Sprintf("Delete(%q)", o.key)
+	case opMerge:
+		return fmt.Sprintf("Merge(%q, %q)", o.key, o.value)
+	case opBatch:
+		s := make([]string, len(o.batch))
+		for i, b := range o.batch {
+			s[i] = b.String()
+		}
+		return "Write{" + strings.Join(s, ", ") + "}"
+	case opGet:
+		return fmt.Sprintf("Get(%q%s)", o.key, snap())
+	case opSnapshot:
+		return "NewSnapshot()"
+	case opReleaseSnapshot:
+		return fmt.Sprintf("ReleaseSnapshot(snapshot %d)", o.n)
+	case opNewIterator:
+		return fmt.Sprintf("NewIterator(%s)", strings.TrimPrefix(snap(), ", "))
+	case opCloseIterator:
+		return fmt.Sprintf("iterator %d: Close()", o.n)
+	case opSeekToFirst:
+		return fmt.Sprintf("iterator %d: SeekToFirst()", o.n)
+	case opSeekToLast:
+		return fmt.Sprintf("iterator %d: SeekToLast()", o.n)
+	case opSeek:
+		return fmt.Sprintf("iterator %d: Seek(%q)", o.n, o.key)
+	case opSeekForPrev:
+		return fmt.Sprintf("iterator %d: SeekForPrev(%q)", o.n, o.key)
+	case opNext:
+		return fmt.Sprintf("iterator %d: Next()", o.n)
+	case opPrev:
+		return fmt.Sprintf("iterator %d: Prev()", o.n)
+	case opCompactRange:
+		return "CompactRange()"
+	case opReopen:
+		return "Close() and Open()"
+	}
+	return fmt.Sprintf("op(%d)", int(o.kind))
+}
+
+func (cfg *ModelConfig) generate(r *rand.Rand) []op {
+	ops := make([]op, cfg.Steps)
+	for i := range ops {
+		ops[i] = cfg.generateOp(r)
+	}
+	return ops
+}
+
+func (cfg *ModelConfig) generateWrite(r *rand.Rand) op {
+	switch n := r.Intn(10); {
+	case n < 6:
+		return op{kind: opPut, key: cfg.Key(r), value: cfg.Value(r)}
+	case n < 8 || cfg.Merge == nil:
+		return op{kind: opDelete, key: cfg.Key(r)}
+	default:
+		return op{kind: opMerge, key: cfg.Key(r), value: cfg.Value(r)}
+	}
+}
+
+func (cfg *ModelConfig) generateOp(r *rand.Rand) op {
+	// n refers to a snapshot or an iterator; the small range makes it
+	// likely to refer to one of the few open.
+	n := r.Intn(4)
+	snap := r.Intn(4) - 1
+	switch x := r.Intn(100); {
+	case x < 35:
+		return cfg.generateWrite(r)
+	case x < 42:
+		o := op{kind: opBatch}
+		for i := r.Intn(5) + 1; i > 0; i-- {
+			o.batch = append(o.batch, cfg.generateWrite(r))
+		}
+		return o
+	case x < 55:
+		return op{kind: opGet, key: cfg.Key(r), n: snap}
+	case x < 58:
+		return op{kind: opSnapshot}
+	case x < 60:
+		return op{kind: opReleaseSnapshot, n: n}
+	case x < 64:
+		return op{kind: opNewIterator, n: snap}
+	case x < 66:
+		return op{kind: opCloseIterator, n: n}
+	case x < 69:
+		return op{kind: opSeekToFirst, n: n}
+	case x < 72:
+		return op{kind: opSeekToLast, n: n}
+	case x < 77:
+		return op{kind: opSeek, key: cfg.Key(r), n: n}
+	case x < 81:
+		return op{kind: opSeekForPrev, key: cfg.Key(r), n: n}
+	case x < 90:
+		return op{kind: opNext, n: n}
+	case x < 97:
+		return op{kind: opPrev, n: n}
+	case x < 99:
+		return op{kind: opCompactRange}
+	default:
+		return op{kind: opReopen}
+	}
+}
+
+// failure is a disagreement between the database and the model, found by
+// the operation at index step.
+type failure struct {
+	step int
+	msg  string
+}
+
+// modelDB runs a sequence of operations against a database and the model
+// side by side.
+type modelDB struct {
+	cfg     *ModelConfig
+	env     *rocksgo.Env
+	options *rocksgo.Options
+	db      *rocksgo.DB
+	ro      *rocksgo.ReadOptions
+	wo      *rocksgo.WriteOptions
+	model   map[string][]byte
+	snaps   []*modelSnapshot
+	iters   []*modelIterator
+}
+
+type modelSnapshot struct {
+	snap  *rocksgo.Snapshot
+	ro    *rocksgo.ReadOptions
+	state map[string][]byte
+}
+
+type modelIterator struct {
+	it     *rocksgo.Iterator
+	keys   [][]byte
+	values [][]byte
+	pos    int
+}
+
+func (m *modelIterator) valid() bool {
+	return m.pos >= 0 && m.pos < len(m.keys)
+}
+
+const modelDBName = "/rocksgotest/model"
+
+// run runs ops against a new database, returning where they first
+// disagree with the model, or nil.
+func (cfg *ModelConfig) run(tb testing.TB, ops []op) *failure {
+	tb.Helper()
+	m := &modelDB{
+		cfg:     cfg,
+		env:     rocksgo.NewMemEnv(),
+		options: rocksgo.NewOptions(),
+		ro:      rocksgo.NewReadOptions(),
+		wo:      rocksgo.NewWriteOptions(),
+		model:   make(map[string][]byte),
+	}
+	defer m.close()
+	m.options.SetEnv(m.env)
+	m.options.SetCreateIfMissing(true)
+	m.options.SetWriteBufferSize(64 << 10)
+	if cfg.Configure != nil {
+		cfg.Configure(m.options)
+	}
+	var err error
+	if m.db, err = rocksgo.Open(modelDBName, m.options); err != nil {
+		tb.Fatalf("rocksgotest: opening an in-memory database failed: %v", err)
+	}
+	for i, o := range ops {
+		if msg := m.apply(o); msg != "" {
+			return &failure{i, fmt.Sprintf("%v: %s", o, msg)}
+		}
+	}
+	if msg := m.checkAll(); msg != "" {
+		return &failure{len(ops), "at the end: " + msg}
+	}
+	return nil
+}
+
+func (m *modelDB) closeReaders() {
+	for _, it := range m.iters {
+		it.it.Close()
+	}
+	m.iters = nil
+	for _, s := range m.snaps {
+		m.db.ReleaseSnapshot(s.snap)
+		s.ro.Close()
+	}
+	m.snaps = nil
+}
+
+func (m *modelDB) close() {
+	if m.db != nil {
+		m.closeReaders()
+		m.db.Close()
+	}
+	m.ro.Close()
+	m.wo.Close()
+	m.options.Close()
+	m.env.Close()
+}
+
+// write applies a write to the model.
+func (m *modelDB) write(o op) {
+	k := string(o.key)
+	switch o.kind {
+	case opPut:
+		m.model[k] = bytes.Clone(o.value)
+	case opDelete:
+		delete(m.model, k)
+	case opMerge:
+		v := m.cfg.Merge(o.key, m.model[k], o.value)
+		if v == nil {
+			v = []byte{}
+		}
+		m.model[k] = bytes.Clone(v)
+	}
+}
+
+// apply runs o against the database and the model, describing how they
+// disagree, if they do.
+func (m *modelDB) apply(o op) string {
+	switch o.kind {
+	case opPut:
+		if err := m.db.Put(m.wo, o.key, o.value); err != nil {
+			return fmt.Sprintf("Put failed: %v", err)
+		}
+		m.write(o)
+	case opDelete:
+		if err := m.db.Delete(m.wo, o.key); err != nil {
+			return fmt.Sprintf("Delete failed: %v", err)
+		}
+		m.write(o)
+	case opMerge:
+		if err := m.db.Merge(m.wo, o.key, o.value); err != nil {
+			return fmt.Sprintf("Merge failed: %v", err)
+		}
+		m.write(o)
+	case opBatch:
+		wb := rocksgo.NewWriteBatch()
+		defer wb.Close()
+		for _, b := range o.batch {
+			switch b.kind {
+			case opPut:
+				wb.Put(b.key, b.value)
+			case opDelete:
+				wb.Delete(b.key)
+			case opMerge:
+				wb.Merge(b.key, b.value)
+			}
+		}
+		if err := m.db.Write(m.wo, wb); err != nil {
+			return fmt.Sprintf("Write failed: %v", err)
+		}
+		for _, b := range o.batch {
+			m.write(b)
+		}
+	case opGet:
+		ro, state := m.ro, m.model
+		if o.n >= 0 && len(m.snaps) > 0 {
+			s := m.snaps[o.n%len(m.snaps)]
+			ro, state = s.ro, s.state
+		}
+		got, err := m.db.Get(ro, o.key)
+		if err != nil {
+			return fmt.Sprintf("Get failed: %v", err)
+		}
+		want := state[string(o.key)]
+		if (got == nil) != (want == nil) || !bytes.Equal(got, want) {
+			return fmt.Sprintf("got %s, want %s", showValue(got), showValue(want))
+		}
+	case opSnapshot:
+		s := &modelSnapshot{snap: m.db.NewSnapshot(), ro: rocksgo.NewReadOptions(), state: maps.Clone(m.model)}
+		s.ro.SetSnapshot(s.snap)
+		m.snaps = append(m.snaps, s)
+	case opReleaseSnapshot:
+		if len(m.snaps) == 0 {
+			break
+		}
+		i := o.n % len(m.snaps)
+		m.db.ReleaseSnapshot(m.snaps[i].snap)
+		m.snaps[i].ro.Close()
+		m.snaps = slices.Delete(m.snaps, i, i+1)
+	case opNewIterator:
+		ro, state := m.ro, m.model
+		if o.n >= 0 && len(m.snaps) > 0 {
+			s := m.snaps[o.n%len(m.snaps)]
+			ro, state = s.ro, s.state
+		}
+		it := &modelIterator{it: m.db.NewIterator(ro), pos: -1}
+		for k := range state {
+			it.keys = append(it.keys, []byte(k))
+		}
+		slices.SortFunc(it.keys, m.cfg.Compare)
+		for _, k := range it.keys {
+			it.values = append(it.values, state[string(k)])
+		}
+		m.iters = append(m.iters, it)
+		return m.checkIterator(it)
+	case opCloseIterator:
+		if len(m.iters) == 0 {
+			break
+		}
+		i := o.n % len(m.iters)
+		m.iters[i].it.Close()
+		m.iters = slices.Delete(m.iters, i, i+1)
+	case opSeekToFirst, opSeekToLast, opSeek, opSeekForPrev, opNext, opPrev:
+		if len(m.iters) == 0 {
+			break
+		}
+		it := m.iters[o.n%len(m.iters)]
+		m.move(it, o)
+		return m.checkIterator(it)
+	case opCompactRange:
+		if err := m.db.CompactRange(rocksgo.Range{}); err != nil {
+			return fmt.Sprintf("CompactRange failed: %v", err)
+		}
+	case opReopen:
+		m.closeReaders()
+		err := m.db.Close()
+		m.db = nil
+		if err != nil {
+			return fmt.Sprintf("Close failed: %v", err)
+		}
+		if m.db, err = rocksgo.Open(modelDBName, m.options); err != nil {
+			return fmt.Sprintf("Open failed: %v", err)
+		}
+	}
+	return ""
+}
+
+// move moves both the iterator and its model. Next and Prev are only valid
+// on a valid iterator, so they do nothing on an invalid one.
+func (m *modelDB) move(it *modelIterator, o op) {
+	switch o.kind {
+	case opSeekToFirst:
+		it.it.SeekToFirst()
+		it.pos = 0
+	case opSeekToLast:
+		it.it.SeekToLast()
+		it.pos = len(it.keys) - 1
+	case opSeek:
+		it.it.Seek(o.key)
+		it.pos, _ = slices.BinarySearchFunc(it.keys, o.key, m.cfg.Compare)
+	case opSeekForPrev:
+		it.it.SeekForPrev(o.key)
+		i, found := slices.BinarySearchFunc(it.keys, o.key, m.cfg.Compare)
+		if !found {
+			i--
+		}
+		it.pos = i
+	case opNext:
+		if it.valid() {
+			it.it.Next()
+			it.pos++
+		}
+	case opPrev:
+		if it.valid() {
+			it.it.Prev()
+			it.pos--
+		}
+	}
+}
+
+func (m *modelDB) checkIterator(it *modelIterator) string {
+	if err := it.it.GetError(); err != nil {
+		return fmt.Sprintf("iterator failed: %v", err)
+	}
+	valid := it.valid()
+	if it.it.Valid() != valid {
+		if valid {
+			return fmt.Sprintf("the iterator is not valid, want it at %q", it.keys[it.pos])
+		}
+		return fmt.Sprintf("the iterator is at %q, want it not valid", it.it.Key())
+	}
+	if !valid {
+		return ""
+	}
+	if k, v := it.it.Key(), it.it.Value(); !bytes.Equal(k, it.keys[it.pos]) || !bytes.Equal(v, it.values[it.pos]) {
+		return fmt.Sprintf("the iterator is at %q = %s, want %q = %s",
+			k, showValue(v), it.keys[it.pos], showValue(it.values[it.pos]))
+	}
+	return ""
+}
+
+// checkAll compares the whole database with the model, both forwards and
+// backwards.
+func (m *modelDB) checkAll() string {
+	it := &modelIterator{it: m.db.NewIterator(m.ro)}
+	defer it.it.Close()
+	for k := range m.model {
+		it.keys = append(it.keys, []byte(k))
+	}
+	slices.SortFunc(it.keys, m.cfg.Compare)
+	for _, k := range it.keys {
+		it.values = append(it.values, m.model[string(k)])
+	}
+	for _, o := range []op{{kind: opSeekToFirst}, {kind: opNext}, {kind: opSeekToLast}, {kind: opPrev}} {
+		m.move(it, o)
+		if msg := m.checkIterator(it); msg != "" {
+			return "scanning the database: " + msg
+		}
+		for (o.kind == opNext || o.kind == opPrev) && it.valid() {
+			m.move(it, o)
+			if msg := m.checkIterator(it); msg != "" {
+				return "scanning the database: " + msg
+			}
+		}
+	}
+	return ""
+}
+
+// shrink looks for the shortest sequence of operations still failing,
+// removing ever smaller chunks of the sequence, then single writes from
+// the batches left.
+func (cfg *ModelConfig) shrink(tb testing.TB, ops []op, f *failure) ([]op, *failure) {
+	tb.Helper()
+	// Operations after the failing one play no part in it.
+	ops = ops[:min(f.step+1, len(ops))]
+	try := func(candidate []op) bool {
+		g := cfg.run(tb, candidate)
+		if g == nil {
+			return false
+		}
+		ops, f = candidate[:min(g.step+1, len(candidate))], g
+		return true
+	}
+	for chunk := len(ops) / 2; chunk > 0; {
+		removed := false
+		for i := 0; i+chunk <= len(ops); {
+			if try(slices.Concat(ops[:i], ops[i+chunk:])) {
+				removed = true
+			} else {
+				i += chunk
+			}
+		}
+		if !removed {
+			chunk /= 2
+		}
+	}
+	for i := 0; i < len(ops); i++ {
+		for j := 0; i < len(ops) && ops[i].kind == opBatch && j < len(ops[i].batch); {
+			candidate := slices.Clone(ops)
+			candidate[i].batch = slices.Delete(slices.Clone(ops[i].batch), j, j+1)
+			if !try(candidate) {
+				j++
+			}
+		}
+	}
+	return ops, f
+}
+
+func showValue(v []byte) string {
+	if v == nil {
+		return "not found"
+	}
+	return fmt.Sprintf("%q", v)
+}
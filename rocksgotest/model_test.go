@@ -0,0 +1,68 @@
+package rocksgotest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ananclub/rocksgo"
+)
+
+// appendOperator appends the operands of a key to its value.
+type appendOperator struct{}
+
+func (appendOperator) Name() string { return "rocksgotest.append" }
+
+func (appendOperator) FullMerge(key, existing []byte, operands [][]byte) ([]byte, bool) {
+	v := append([]byte(nil), existing...)
+	for _, o := range operands {
+		v = append(v, o...)
+	}
+	return v, true
+}
+
+func appendModel(key, existing, operand []byte) []byte {
+	return append(append([]byte(nil), existing...), operand...)
+}
+
+func TestCheckModel(t *testing.T) {
+	CheckModel(t, ModelConfig{Seed: 1, Runs: 10})
+}
+
+func TestCheckModelMerge(t *testing.T) {
+	CheckModel(t, ModelConfig{
+		Configure: func(o *rocksgo.Options) { o.SetMergeOperator(appendOperator{}) },
+		Merge:     appendModel,
+		Seed:      1,
+		Runs:      10,
+	})
+}
+
+// fatalRecorder records the failure of CheckModel instead of failing the
+// test.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...any) {
+	r.msg = fmt.Sprintf(format, args...)
+}
+
+func TestCheckModelShrinks(t *testing.T) {
+	r := &fatalRecorder{TB: t}
+	CheckModel(r, ModelConfig{
+		Configure: func(o *rocksgo.Options) { o.SetMergeOperator(appendOperator{}) },
+		// A wrong model of the merge operator, ignoring the existing value.
+		Merge: func(key, existing, operand []byte) []byte { return operand },
+		Seed:  1,
+	})
+	if r.msg == "" {
+		t.Fatal("CheckModel did not fail against a wrong model")
+	}
+	// A Put or a Merge followed by a Merge of the same key is enough,
+	// possibly within a single Write.
+	if !strings.Contains(r.msg, "after these 1 operations") && !strings.Contains(r.msg, "after these 2 operations") {
+		t.Errorf("the failing sequence was not shrunk to 2 operations or less:\n%s", r.msg)
+	}
+}
@@ -0,0 +1,42 @@
+// Package rocksgotest provides helpers for testing code that uses rocksgo.
+package rocksgotest
+
+import (
+	"testing"
+
+	"github.com/ananclub/rocksgo"
+)
+
+// OpenMemDB opens a new, empty database kept entirely in memory, and
+// closes it when the test and its subtests finish, by way of tb.Cleanup.
+//
+// The database is created with small write buffers so that tests exercise
+// flushes and compactions quickly. The functions given may change the
+// Options further before the database is opened; the Env must be left
+// alone.
+func OpenMemDB(tb testing.TB, configure ...func(*rocksgo.Options)) *rocksgo.DB {
+	tb.Helper()
+	env := rocksgo.NewMemEnv()
+	options := rocksgo.NewOptions()
+	options.SetEnv(env)
+	options.SetCreateIfMissing(true)
+	options.SetErrorIfExists(true)
+	options.SetWriteBufferSize(1 << 20)
+	for _, f := range configure {
+		f(options)
+	}
+	db, err := rocksgo.Open("/rocksgotest/"+tb.Name(), options)
+	if err != nil {
+		options.Close()
+		env.Close()
+		tb.Fatalf("rocksgotest: opening an in-memory database failed: %v", err)
+	}
+	tb.Cleanup(func() {
+		if err := db.Close(); err != nil && err != rocksgo.ErrClosed {
+			tb.Errorf("rocksgotest: closing the in-memory database failed: %v", err)
+		}
+		options.Close()
+		env.Close()
+	})
+	return db
+}
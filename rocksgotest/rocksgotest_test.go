@@ -0,0 +1,32 @@
+package rocksgotest
+
+import (
+	"testing"
+
+	"github.com/ananclub/rocksgo"
+)
+
+func TestOpenMemDB(t *testing.T) {
+	ro := rocksgo.NewReadOptions()
+	defer ro.Close()
+	wo := rocksgo.NewWriteOptions()
+	defer wo.Close()
+
+	for i := 0; i < 2; i++ {
+		t.Run("db", func(t *testing.T) {
+			db := OpenMemDB(t, func(o *rocksgo.Options) { o.SetParanoidChecks(true) })
+			if v, err := db.Get(ro, []byte("k")); err != nil || v != nil {
+				t.Fatalf("a new database should be empty, got %q, %v", v, err)
+			}
+			if err := db.Put(wo, []byte("k"), []byte("v")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := db.CompactRange(rocksgo.Range{}); err != nil {
+				t.Fatalf("CompactRange failed: %v", err)
+			}
+			if v, err := db.Get(ro, []byte("k")); err != nil || string(v) != "v" {
+				t.Errorf("Get = %q, %v", v, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package rocksgo
+
+import (
+	"bytes"
+	"iter"
+
+	"github.com/ananclub/rocksgo/internal/scanerr"
+)
+
+// The scans below wrap an Iterator in an iter.Seq2, for use with range:
+//
+//	var err error
+//	for k, v := range db.Range(ro, start, limit, &err) {
+//		munge(k, v)
+//	}
+//	if err != nil {
+//		...
+//	}
+//
+// The Iterator is created when the loop starts and closed when it ends,
+// including on an early break. Once the loop is over, the error of the
+// Iterator, or nil, is stored in *errp. errp must not be nil: the scans
+// panic when created with a nil errp, before reading anything. Every key and
+// value yielded is a fresh copy the loop body may keep.
+//
+// Range, Prefix and Backward compare keys bytewise, and so only suit
+// databases using the default comparator.
+
+// All returns the key-value pairs of the database, in order.
+func (db *DB) All(ro *ReadOptions, errp *error) iter.Seq2[[]byte, []byte] {
+	return db.scan(ro, errp, func(it *Iterator) { it.SeekToFirst() }, (*Iterator).Next,
+		func([]byte) bool { return true })
+}
+
+// Range returns the key-value pairs of the database from the key start up
+// to but not including the key limit, in order. A nil start begins at the
+// first key, and a nil limit ends at the last one.
+func (db *DB) Range(ro *ReadOptions, start, limit []byte, errp *error) iter.Seq2[[]byte, []byte] {
+	return db.scan(ro, errp, func(it *Iterator) { it.Seek(start) }, (*Iterator).Next,
+		func(k []byte) bool { return limit == nil || bytes.Compare(k, limit) < 0 })
+}
+
+// Prefix returns the key-value pairs of the database whose keys begin with
+// prefix, in order.
+func (db *DB) Prefix(ro *ReadOptions, prefix []byte, errp *error) iter.Seq2[[]byte, []byte] {
+	return db.scan(ro, errp, func(it *Iterator) { it.Seek(prefix) }, (*Iterator).Next,
+		func(k []byte) bool { return bytes.HasPrefix(k, prefix) })
+}
+
+// Backward returns the same key-value pairs as Range, in reverse order.
+func (db *DB) Backward(ro *ReadOptions, start, limit []byte, errp *error) iter.Seq2[[]byte, []byte] {
+	seek := func(it *Iterator) {
+		if limit == nil {
+			it.SeekToLast()
+			return
+		}
+		// Seek lands on the first key at or after limit, which is past the
+		// end of the range, or nowhere if every key is before limit.
+		it.Seek(limit)
+		if it.Valid() {
+			it.Prev()
+		} else if it.GetError() == nil {
+			it.SeekToLast()
+		}
+	}
+	return db.scan(ro, errp, seek, (*Iterator).Prev,
+		func(k []byte) bool { return start == nil || bytes.Compare(k, start) >= 0 })
+}
+
+func (db *DB) scan(ro *ReadOptions, errp *error, seek, step func(*Iterator), in func([]byte) bool) iter.Seq2[[]byte, []byte] {
+	scanerr.Check(errp)
+	return func(yield func([]byte, []byte) bool) {
+		it := db.NewIterator(ro)
+		defer it.Close()
+		for seek(it); it.Valid(); step(it) {
+			k := it.Key()
+			if !in(k) || !yield(k, it.Value()) {
+				break
+			}
+		}
+		*errp = it.GetError()
+	}
+}
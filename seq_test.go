@@ -0,0 +1,103 @@
+package rocksgo
+
+import (
+	"iter"
+	"strings"
+	"testing"
+)
+
+func collect(t *testing.T, seq iter.Seq2[[]byte, []byte], err *error) string {
+	var keys []string
+	for k, v := range seq {
+		if string(k) != string(v) {
+			t.Errorf("key %q has value %q", k, v)
+		}
+		keys = append(keys, string(k))
+	}
+	if *err != nil {
+		t.Errorf("scan failed: %v", *err)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestScans(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	for _, k := range []string{"a", "ab", "abc", "b", "ba", "c"} {
+		if err := db.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	var err error
+	for _, c := range []struct {
+		name string
+		seq  iter.Seq2[[]byte, []byte]
+		want string
+	}{
+		{"All", db.All(ro, &err), "a,ab,abc,b,ba,c"},
+		{"Range", db.Range(ro, []byte("ab"), []byte("ba"), &err), "ab,abc,b"},
+		{"Range from start", db.Range(ro, nil, []byte("b"), &err), "a,ab,abc"},
+		{"Range to end", db.Range(ro, []byte("b"), nil, &err), "b,ba,c"},
+		{"Range empty", db.Range(ro, []byte("x"), nil, &err), ""},
+		{"Prefix", db.Prefix(ro, []byte("a"), &err), "a,ab,abc"},
+		{"Prefix missing", db.Prefix(ro, []byte("bb"), &err), ""},
+		{"Backward", db.Backward(ro, []byte("ab"), []byte("ba"), &err), "b,abc,ab"},
+		{"Backward all", db.Backward(ro, nil, nil, &err), "c,ba,b,abc,ab,a"},
+		{"Backward past end", db.Backward(ro, []byte("b"), []byte("z"), &err), "c,ba,b"},
+		{"Backward before start", db.Backward(ro, nil, []byte("a"), &err), ""},
+	} {
+		if got := collect(t, c.seq, &err); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestScanBreakClosesIterator(t *testing.T) {
+	db, ro, wo := openBinaryKeysDb(t)
+	for _, k := range []string{"a", "b", "c"} {
+		if err := db.Put(wo, []byte(k), []byte(k)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	var err error
+	for range db.All(ro, &err) {
+		break
+	}
+	if err != nil {
+		t.Errorf("scan failed: %v", err)
+	}
+	if n := db.refs.Load(); n != 0 {
+		t.Errorf("the Iterator should be closed after a break, %d references left", n)
+	}
+}
+
+func TestScanClosedDB(t *testing.T) {
+	db, ro, _ := openBinaryKeysDb(t)
+	db.Close()
+	var err error
+	for range db.All(ro, &err) {
+		t.Errorf("a closed DB should yield nothing")
+	}
+	if err != ErrClosed {
+		t.Errorf("scanning a closed DB returned %v, want ErrClosed", err)
+	}
+}
+
+func TestScanNilErrorPointer(t *testing.T) {
+	db, ro, _ := openBinaryKeysDb(t)
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s with a nil error pointer did not panic", name)
+			}
+		}()
+		f()
+	}
+	// The scans panic when created, without being run, so that the panic
+	// never depends on what reading the database meets.
+	mustPanic("All", func() { db.All(ro, nil) })
+	mustPanic("Range", func() { db.Range(ro, nil, nil, nil) })
+	mustPanic("Prefix", func() { db.Prefix(ro, nil, nil) })
+	mustPanic("Backward", func() { db.Backward(ro, nil, nil, nil) })
+}
@@ -0,0 +1,89 @@
+// Reading SST files outside of any database, which the C API does not
+// offer.
+
+#include <memory>
+#include <string>
+
+#include "rocksdb/sst_file_reader.h"
+#include "rocksdb/table_properties.h"
+#include "rocksgo_internal.h"
+
+using rocksdb::SstFileReader;
+using rocksdb::Status;
+using rocksdb::TableProperties;
+
+struct rocksgo_sst_file_reader_t {
+  std::unique_ptr<SstFileReader> rep;
+};
+
+extern "C" {
+
+rocksgo_sst_file_reader_t* rocksgo_sst_file_reader_create(
+    const rocksdb_options_t* opt) {
+  return new rocksgo_sst_file_reader_t{
+      std::unique_ptr<SstFileReader>(new SstFileReader(opt->rep))};
+}
+
+void rocksgo_sst_file_reader_open(rocksgo_sst_file_reader_t* r,
+                                  const char* path, char** errptr) {
+  Status s = r->rep->Open(path);
+  if (!s.ok()) {
+    rocksgo::SaveError(errptr, s);
+  }
+}
+
+void rocksgo_sst_file_reader_destroy(rocksgo_sst_file_reader_t* r) {
+  delete r;
+}
+
+rocksdb_iterator_t* rocksgo_sst_file_reader_new_iterator(
+    rocksgo_sst_file_reader_t* r, const rocksdb_readoptions_t* ro) {
+  rocksdb_iterator_t* it = new rocksdb_iterator_t;
+  it->rep = r->rep->NewIterator(ro->rep);
+  return it;
+}
+
+void rocksgo_sst_file_reader_verify_checksum(rocksgo_sst_file_reader_t* r,
+                                             const rocksdb_readoptions_t* ro,
+                                             char** errptr) {
+  Status s = r->rep->VerifyChecksum(ro->rep);
+  if (!s.ok()) {
+    rocksgo::SaveError(errptr, s);
+  }
+}
+
+void rocksgo_sst_file_reader_get_properties(rocksgo_sst_file_reader_t* r,
+                                            rocksgo_table_properties_t* props) {
+  std::shared_ptr<const TableProperties> p = r->rep->GetTableProperties();
+  if (p == nullptr) {
+    // No file is open; the Go side checks for this, but do not crash.
+    *props = rocksgo_table_properties_t{};
+    return;
+  }
+  props->num_entries = p->num_entries;
+  props->num_deletions = p->num_deletions;
+  props->num_merge_operands = p->num_merge_operands;
+  props->num_range_deletions = p->num_range_deletions;
+  props->raw_key_size = p->raw_key_size;
+  props->raw_value_size = p->raw_value_size;
+  props->data_size = p->data_size;
+  props->index_size = p->index_size;
+  props->filter_size = p->filter_size;
+  props->num_data_blocks = p->num_data_blocks;
+  props->format_version = p->format_version;
+  props->oldest_key_time = p->oldest_key_time;
+  props->file_creation_time = p->file_creation_time;
+  props->column_family_name = strdup(p->column_family_name.c_str());
+  props->comparator_name = strdup(p->comparator_name.c_str());
+  props->compression_name = strdup(p->compression_name.c_str());
+  props->filter_policy_name = strdup(p->filter_policy_name.c_str());
+}
+
+void rocksgo_table_properties_free(rocksgo_table_properties_t* props) {
+  free(props->column_family_name);
+  free(props->comparator_name);
+  free(props->compression_name);
+  free(props->filter_policy_name);
+}
+
+}  // extern "C"
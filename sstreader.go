@@ -0,0 +1,186 @@
+package rocksgo
+
+// #cgo LDFLAGS: -lrocksdb
+// #include <stdlib.h>
+// #include "rocksdb/c.h"
+// #include "rocksgo.h"
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+// SstFileReader reads a single SST file on its own, without opening the
+// database it belongs to, which is handy to inspect a file, or to check it
+// before ingesting it.
+//
+// To prevent memory leaks, Close must be called on an SstFileReader when
+// the program no longer needs it, after closing its Iterators.
+type SstFileReader struct {
+	reader *C.rocksgo_sst_file_reader_t
+	opened bool
+	leak   *leakRecord
+}
+
+// TableProperties describes the content of an SST file.
+type TableProperties struct {
+	// NumEntries counts the entries of the file, including the deletions
+	// and merge operands, but not the range deletions.
+	NumEntries        uint64
+	NumDeletions      uint64
+	NumMergeOperands  uint64
+	NumRangeDeletions uint64
+	RawKeySize        uint64
+	RawValueSize      uint64
+	DataSize          uint64
+	IndexSize         uint64
+	FilterSize        uint64
+	NumDataBlocks     uint64
+	FormatVersion     uint64
+	ColumnFamilyName  string
+	ComparatorName    string
+	CompressionName   string
+	FilterPolicyName  string
+	// CreationTime is when the file was written, and OldestKeyTime when
+	// the oldest of its keys was, or the zero Time if unknown.
+	CreationTime  time.Time
+	OldestKeyTime time.Time
+	// SmallestKey and LargestKey are the first and the last live key of
+	// the file, as seen by the Iterators of the SstFileReader: the keys of
+	// deletions, and those they delete in the file, are skipped, so the
+	// range may be narrower than the file's, and the keys are nil if the
+	// file holds no live key.
+	SmallestKey []byte
+	LargestKey  []byte
+}
+
+// NewSstFileReader creates an SstFileReader. The Options must be those of
+// the database the files come from, or at least use the same comparator.
+// They may be closed once the SstFileReader is created.
+func NewSstFileReader(o *Options) *SstFileReader {
+	r := &SstFileReader{reader: C.rocksgo_sst_file_reader_create(o.Opt)}
+	r.leak = trackResource(r, "SstFileReader", nil)
+	return r
+}
+
+// Open opens the SST file at path. The other methods return
+// ErrSstFileNotOpen until it succeeds.
+func (r *SstFileReader) Open(path string) error {
+	if r.reader == nil {
+		return ErrClosed
+	}
+	var errStr *C.char
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	C.rocksgo_sst_file_reader_open(r.reader, cpath, &errStr)
+	if err := statusError(errStr); err != nil {
+		return err
+	}
+	r.opened = true
+	return nil
+}
+
+// check returns the error of a call on a reader that was closed, or has no
+// file open.
+func (r *SstFileReader) check() error {
+	if r.reader == nil {
+		return ErrClosed
+	}
+	if !r.opened {
+		return ErrSstFileNotOpen
+	}
+	return nil
+}
+
+// NewIterator returns an Iterator over the entries of the file, in order.
+// As in a database, the keys deleted in the file are skipped.
+//
+// The Iterator must be closed before the SstFileReader. If the reader has
+// no file open, the Iterator returned is invalid and its GetError returns
+// why.
+func (r *SstFileReader) NewIterator(ro *ReadOptions) *Iterator {
+	if err := r.check(); err != nil {
+		return &Iterator{err: err}
+	}
+	it := &Iterator{Iter: C.rocksgo_sst_file_reader_new_iterator(r.reader, ro.Opt)}
+	it.leak = trackResource(it, "Iterator", nil)
+	return it
+}
+
+// VerifyChecksum reads the whole file, checking the checksum of every
+// block.
+func (r *SstFileReader) VerifyChecksum() error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	ro := NewReadOptions()
+	defer ro.Close()
+	var errStr *C.char
+	C.rocksgo_sst_file_reader_verify_checksum(r.reader, ro.Opt, &errStr)
+	return statusError(errStr)
+}
+
+// Properties returns the properties of the file.
+func (r *SstFileReader) Properties() (TableProperties, error) {
+	if err := r.check(); err != nil {
+		return TableProperties{}, err
+	}
+	var p C.rocksgo_table_properties_t
+	C.rocksgo_sst_file_reader_get_properties(r.reader, &p)
+	defer C.rocksgo_table_properties_free(&p)
+	props := TableProperties{
+		NumEntries:        uint64(p.num_entries),
+		NumDeletions:      uint64(p.num_deletions),
+		NumMergeOperands:  uint64(p.num_merge_operands),
+		NumRangeDeletions: uint64(p.num_range_deletions),
+		RawKeySize:        uint64(p.raw_key_size),
+		RawValueSize:      uint64(p.raw_value_size),
+		DataSize:          uint64(p.data_size),
+		IndexSize:         uint64(p.index_size),
+		FilterSize:        uint64(p.filter_size),
+		NumDataBlocks:     uint64(p.num_data_blocks),
+		FormatVersion:     uint64(p.format_version),
+		ColumnFamilyName:  C.GoString(p.column_family_name),
+		ComparatorName:    C.GoString(p.comparator_name),
+		CompressionName:   C.GoString(p.compression_name),
+		FilterPolicyName:  C.GoString(p.filter_policy_name),
+		CreationTime:      unixTime(p.file_creation_time),
+		OldestKeyTime:     unixTime(p.oldest_key_time),
+	}
+
+	ro := NewReadOptions()
+	defer ro.Close()
+	it := r.NewIterator(ro)
+	defer it.Close()
+	if it.SeekToFirst(); it.Valid() {
+		props.SmallestKey = it.Key()
+	}
+	if it.SeekToLast(); it.Valid() {
+		props.LargestKey = it.Key()
+	}
+	return props, it.GetError()
+}
+
+// Close releases the SstFileReader and the file it has open.
+//
+// Closing an SstFileReader more than once is a no-op.
+func (r *SstFileReader) Close() {
+	if r.reader == nil {
+		return
+	}
+	C.rocksgo_sst_file_reader_destroy(r.reader)
+	r.reader = nil
+	r.opened = false
+	r.leak.untrack()
+}
+
+// unixTime converts seconds since the Unix epoch, 0 meaning unknown, into a
+// Time.
+func unixTime(sec C.uint64_t) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(sec), 0)
+}
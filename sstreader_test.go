@@ -0,0 +1,156 @@
+package rocksgo
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSstFileReader(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	defer options.Close()
+	options.SetCreateIfMissing(true)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	wo := NewWriteOptions()
+	defer wo.Close()
+	for i := 0; i < 100; i++ {
+		if err := db.Put(wo, []byte(fmt.Sprintf("key%03d", i)), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := db.CompactRange(Range{}); err != nil {
+		t.Fatalf("CompactRange failed: %v", err)
+	}
+	db.Close()
+
+	files, err := filepath.Glob(filepath.Join(dbname, "*.sst"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("want a single SST file, got %v, %v", files, err)
+	}
+	r := NewSstFileReader(options)
+	defer r.Close()
+	if err := r.Open(files[0]); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := r.VerifyChecksum(); err != nil {
+		t.Errorf("VerifyChecksum failed: %v", err)
+	}
+	props, err := r.Properties()
+	if err != nil {
+		t.Fatalf("Properties failed: %v", err)
+	}
+	if props.NumEntries != 100 || props.RawKeySize == 0 || props.DataSize == 0 {
+		t.Errorf("unexpected properties %+v", props)
+	}
+	if string(props.SmallestKey) != "key000" || string(props.LargestKey) != "key099" {
+		t.Errorf("keys range from %q to %q, want key000 to key099", props.SmallestKey, props.LargestKey)
+	}
+
+	ro := NewReadOptions()
+	defer ro.Close()
+	it := r.NewIterator(ro)
+	defer it.Close()
+	n := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		n++
+	}
+	if err := it.GetError(); err != nil || n != 100 {
+		t.Errorf("iterated over %d entries, %v, want 100", n, err)
+	}
+
+	bad := NewSstFileReader(options)
+	defer bad.Close()
+	if err := bad.Open(filepath.Join(dbname, "CURRENT")); err == nil {
+		t.Errorf("opening a file that is not an SST file succeeded")
+	}
+	if _, err := bad.Properties(); !errors.Is(err, ErrSstFileNotOpen) {
+		t.Errorf("Properties after a failed Open = %v, want ErrSstFileNotOpen", err)
+	}
+	if err := bad.VerifyChecksum(); !errors.Is(err, ErrSstFileNotOpen) {
+		t.Errorf("VerifyChecksum after a failed Open = %v, want ErrSstFileNotOpen", err)
+	}
+	badIt := bad.NewIterator(ro)
+	if badIt.SeekToFirst(); badIt.Valid() || !errors.Is(badIt.GetError(), ErrSstFileNotOpen) {
+		t.Errorf("an Iterator of a reader without file should be invalid, with ErrSstFileNotOpen")
+	}
+	badIt.Close()
+
+	it.Close()
+	r.Close()
+	if _, err := r.Properties(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Properties after Close = %v, want ErrClosed", err)
+	}
+	if err := r.Open(files[0]); !errors.Is(err, ErrClosed) {
+		t.Errorf("Open after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestSstFileReaderDeletions(t *testing.T) {
+	dbname := tempDir(t)
+	defer deleteDBDirectory(t, dbname)
+	options := NewOptions()
+	defer options.Close()
+	options.SetCreateIfMissing(true)
+	db, err := Open(dbname, options)
+	if err != nil {
+		t.Fatalf("Database could not be opened: %v", err)
+	}
+	wo := NewWriteOptions()
+	defer wo.Close()
+	for i := 0; i < 10; i++ {
+		if err := db.Put(wo, []byte(fmt.Sprintf("key%d", i)), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	// The Snapshot keeps the deleted entries, and so the deletions, in the
+	// file compaction writes.
+	snap := db.NewSnapshot()
+	for _, k := range []string{"key0", "key9"} {
+		if err := db.Delete(wo, []byte(k)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	}
+	if err := db.CompactRange(Range{}); err != nil {
+		t.Fatalf("CompactRange failed: %v", err)
+	}
+	db.ReleaseSnapshot(snap)
+	db.Close()
+
+	files, err := filepath.Glob(filepath.Join(dbname, "*.sst"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("want a single SST file, got %v, %v", files, err)
+	}
+	r := NewSstFileReader(options)
+	defer r.Close()
+	if err := r.Open(files[0]); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	props, err := r.Properties()
+	if err != nil {
+		t.Fatalf("Properties failed: %v", err)
+	}
+	if props.NumEntries != 12 || props.NumDeletions != 2 {
+		t.Errorf("the file has %d entries and %d deletions, want 12 and 2", props.NumEntries, props.NumDeletions)
+	}
+	if string(props.SmallestKey) != "key1" || string(props.LargestKey) != "key8" {
+		t.Errorf("live keys range from %q to %q, want key1 to key8", props.SmallestKey, props.LargestKey)
+	}
+
+	ro := NewReadOptions()
+	defer ro.Close()
+	it := r.NewIterator(ro)
+	defer it.Close()
+	n := 0
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		n++
+	}
+	if err := it.GetError(); err != nil || n != 8 {
+		t.Errorf("iterated over %d live entries, %v, want 8", n, err)
+	}
+}
@@ -0,0 +1,62 @@
+package rocksgo
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// Tail calls fn with every key-value pair of the database from the key start
+// on, in order, and then keeps following the keys written after it, such as
+// those appended to a log keyed by sequence number. Once it has caught up, it
+// looks for new keys every poll interval. A nil start begins at the first
+// key.
+//
+// Tail uses a single tailing Iterator throughout, as set by
+// ReadOptions.SetTailing, rather than a new Iterator on every poll. Only keys
+// after the last one seen are reported, so keys written behind it are
+// missed.
+//
+// Tail returns when fn returns an error, which it passes on, or when ctx is
+// done, returning ctx.Err(), or with the first error of the Iterator.
+func (db *DB) Tail(ctx context.Context, start []byte, poll time.Duration, fn func(key, value []byte) error) error {
+	ro := NewReadOptions()
+	defer ro.Close()
+	ro.SetTailing(true)
+	ro.SetFillCache(false)
+	it := db.NewIteratorContext(ctx, ro)
+	defer it.Close()
+
+	var last []byte
+	it.Seek(start)
+	for {
+		for ; it.Valid(); it.Next() {
+			k := it.Key()
+			if last != nil && bytes.Equal(k, last) {
+				continue
+			}
+			if err := fn(k, it.Value()); err != nil {
+				return err
+			}
+			last = k
+		}
+		if err := it.GetError(); err != nil {
+			return err
+		}
+
+		t := time.NewTimer(poll)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		// An exhausted tailing Iterator only sees new keys after a Seek,
+		// which lands back on the last key reported, skipped above.
+		if last == nil {
+			it.Seek(start)
+		} else {
+			it.Seek(last)
+		}
+	}
+}